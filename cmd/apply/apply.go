@@ -0,0 +1,66 @@
+package apply
+
+import (
+	"os"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+	"github.com/spf13/cobra"
+)
+
+var ApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Install everything declared in a nanolayer.yaml manifest",
+	Long:  `Validate a nanolayer.yaml manifest and install all of its packages and tools in one run.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := nanolayer.ApplyOptions{}
+		opts.File, _ = cmd.Flags().GetString("file")
+		opts.Locked, _ = cmd.Flags().GetBool("locked")
+		opts.LockFile, _ = cmd.Flags().GetString("lock-file")
+		opts.Jobs, _ = cmd.Flags().GetInt("jobs")
+		opts.FailFast, _ = cmd.Flags().GetBool("fail-fast")
+		opts.User, _ = cmd.Flags().GetString("user")
+		opts.Scope, _ = cmd.Flags().GetString("scope")
+
+		if value, _ := cmd.Flags().GetString("target-arch"); value != "" {
+			parsed, ok := nanolayer.ParseArchitecture(value)
+			if !ok {
+				return nanolayer.Usagef("unknown target architecture %q", value)
+			}
+			opts.Architecture = parsed
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			format, _ := cmd.Flags().GetString("output")
+			opts.Output = os.Stderr
+			p, err := nanolayer.PlanApply(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if err := p.Write(os.Stdout, format); err != nil {
+				return err
+			}
+			return p.Err()
+		}
+
+		results, err := nanolayer.Apply(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+
+		nanolayer.WriteSummary(os.Stdout, results)
+		return nanolayer.Err(results)
+	},
+}
+
+func init() {
+	ApplyCmd.Flags().StringP("file", "f", nanolayer.DefaultManifest, "Path to the manifest file")
+	ApplyCmd.Flags().Bool("locked", false, "Install exactly what the lock file pins and fail if it is out of date")
+	ApplyCmd.Flags().IntP("jobs", "j", 1, "Number of tools to resolve, download and install concurrently")
+	ApplyCmd.Flags().Bool("fail-fast", false, "Stop installing further items after the first failure")
+	ApplyCmd.Flags().Bool("dry-run", false, "Resolve every item and print what would be installed, without installing anything")
+	ApplyCmd.Flags().StringP("output", "o", "text", "Format of the --dry-run plan: text or json")
+	ApplyCmd.Flags().String("target-arch", "", "Install tools for this architecture instead of the detected one")
+	ApplyCmd.Flags().String("user", "", "Install tools for this user, defaults to $_REMOTE_USER, $_CONTAINER_USER, $SUDO_USER or the current user")
+	ApplyCmd.Flags().String("scope", "", "Install tools system-wide (system) or below ~/.local of the user (user), defaults to system with root privileges and user without")
+	ApplyCmd.Flags().String("lock-file", "", "Path to the lock file (defaults to nanolayer.lock next to the manifest)")
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+	"github.com/spf13/cobra"
+)
+
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the download cache",
+	Long:  `Inspect and clean the shared download cache configured with --cache-dir or NANOLAYER_CACHE_DIR.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// If no subcommand is provided, show help
+		return cmd.Help()
+	},
+}
+
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List cached downloads",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireCache(); err != nil {
+			return err
+		}
+
+		entries, err := nanolayer.ListCache()
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			fmt.Printf("%s\t%d\t%s\t%s\n",
+				entry.SHA256[:12],
+				entry.Size,
+				entry.LastUsed.Format(time.RFC3339),
+				entry.URL)
+		}
+		return nil
+	},
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached downloads that have not been used recently",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireCache(); err != nil {
+			return err
+		}
+
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		freed, err := nanolayer.PruneCache(olderThan)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Freed %d bytes\n", freed)
+		return nil
+	},
+}
+
+var clearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached downloads",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireCache(); err != nil {
+			return err
+		}
+
+		if err := nanolayer.ClearCache(); err != nil {
+			return err
+		}
+		fmt.Printf("Cleared download cache %s\n", nanolayer.CacheDir())
+		return nil
+	},
+}
+
+func requireCache() error {
+	if nanolayer.CacheDir() == "" {
+		return nanolayer.Usagef("no download cache configured, use --cache-dir or NANOLAYER_CACHE_DIR")
+	}
+	return nil
+}
+
+func init() {
+	pruneCmd.Flags().Duration("older-than", 30*24*time.Hour, "Remove entries not used within this duration (e.g., --older-than 168h)")
+
+	CacheCmd.AddCommand(lsCmd)
+	CacheCmd.AddCommand(pruneCmd)
+	CacheCmd.AddCommand(clearCmd)
+}
@@ -0,0 +1,98 @@
+package env
+
+import (
+	"fmt"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+	"github.com/spf13/cobra"
+)
+
+var EnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage PATH and environment variables in shell startup files",
+	Long: `Write PATH entries and environment variables into marker-delimited blocks of the shell
+startup files, /etc/profile.d and the system bash, zsh and fish configuration for the system scope,
+or ~/.profile, ~/.bashrc, ~/.zshrc and fish conf.d of the user for the user scope. Running a command
+again updates its block instead of adding another one.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// If no subcommand is provided, show help
+		return cmd.Help()
+	},
+}
+
+var addPathCmd = &cobra.Command{
+	Use:   "add-path <dir>...",
+	Short: "Prepend directories to PATH, relative ones are made absolute",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := envOptions(cmd)
+		for _, dir := range args {
+			entry, err := nanolayer.ParsePathEntry(dir)
+			if err != nil {
+				return &nanolayer.UsageError{Err: err}
+			}
+			if err := report(nanolayer.AddEnv(cmd.Context(), opts, entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set KEY=VALUE...",
+	Short: "Export environment variables",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := envOptions(cmd)
+		for _, assignment := range args {
+			entry, err := nanolayer.ParseVarEntry(assignment)
+			if err != nil {
+				return &nanolayer.UsageError{Err: err}
+			}
+			if err := report(nanolayer.AddEnv(cmd.Context(), opts, entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <dir|KEY>...",
+	Short: "Remove PATH directories or environment variables added before",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := envOptions(cmd)
+		for _, name := range args {
+			if err := report(nanolayer.RemoveEnv(cmd.Context(), opts, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// envOptions reads the --scope and --user flags
+func envOptions(cmd *cobra.Command) nanolayer.EnvOptions {
+	opts := nanolayer.EnvOptions{}
+	opts.Scope, _ = cmd.Flags().GetString("scope")
+	opts.User, _ = cmd.Flags().GetString("user")
+	return opts
+}
+
+// report prints the changed files and passes err on
+func report(changed []string, err error) error {
+	for _, file := range changed {
+		fmt.Printf("Updated %s\n", file)
+	}
+	return err
+}
+
+func init() {
+	EnvCmd.PersistentFlags().String("user", "", "Update the startup files of this user, defaults to $_REMOTE_USER, $_CONTAINER_USER, $SUDO_USER or the current user")
+	EnvCmd.PersistentFlags().String("scope", "", "Update the system-wide (system) or the user's (user) startup files, defaults to system with root privileges and user without")
+	EnvCmd.AddCommand(addPathCmd)
+	EnvCmd.AddCommand(setCmd)
+	EnvCmd.AddCommand(removeCmd)
+}
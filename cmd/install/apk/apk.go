@@ -2,34 +2,47 @@ package apk
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
-	"github.com/devcontainer-community/nanolayer-go/internal/installers/apk"
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
 	"github.com/spf13/cobra"
 )
 
 var ApkCmd = &cobra.Command{
 	Use:   "apk [packages...]",
 	Short: "Install packages using APK (Alpine Package Keeper)",
-	Long:  `Install packages on Alpine Linux using the APK package manager.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Long:  `Install packages on Alpine Linux and distributions based on it, such as Wolfi, using the APK package manager.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
-			fmt.Println("Error: At least one package name is required.")
-			os.Exit(1)
+			return nanolayer.Usagef("at least one package name is required")
 		}
 
-		fmt.Printf("Installing packages: %v\n", args)
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			format, _ := cmd.Flags().GetString("output")
+			step, err := nanolayer.PlanAPK(cmd.Context(), nanolayer.APKOptions{Packages: args})
+			if err != nil {
+				step.Fail(err)
+			}
+			p := &nanolayer.Plan{Steps: []nanolayer.Step{step}}
+			if err := p.Write(os.Stdout, format); err != nil {
+				return err
+			}
+			return p.Err()
+		}
+
+		slog.Info("Installing packages", "event", nanolayer.EventInstall, "packages", args)
 
-		err := apk.InstallPackage(args)
-		if err != nil {
-			fmt.Printf("Error during installation: %v\n", err)
-			os.Exit(1)
+		if err := nanolayer.InstallAPK(cmd.Context(), nanolayer.APKOptions{Packages: args}); err != nil {
+			return err
 		}
 
 		fmt.Println("Installation completed successfully!")
+		return nil
 	},
 }
 
 func init() {
-
+	ApkCmd.Flags().Bool("dry-run", false, "Print the packages that would be installed, without installing anything")
+	ApkCmd.Flags().StringP("output", "o", "text", "Format of the --dry-run plan: text or json")
 }
@@ -1,103 +1,193 @@
 package github
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"strings"
 
-	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
 	"github.com/spf13/cobra"
 )
 
 var GithubCmd = &cobra.Command{
-	Use:   "github",
+	Use:   "github [owner/repo[@version]...]",
 	Short: "Install packages from GitHub releases",
-	Long:  `Install packages and tools from GitHub releases.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) > 0 {
-			fmt.Printf("Arguments: %v\n", args)
-		}
-		// Add your GitHub installation logic here
-		if len(args) < 1 {
-			fmt.Println("Error: GitHub repository argument is required (format: owner/repo).")
-			os.Exit(1)
-		}
-		repo := args[0]
-		fmt.Printf("Installing from GitHub repository: %s\n", repo)
+	Long: `Install packages and tools from GitHub releases.
 
-		parts := strings.Split(repo, "/")
-		if len(parts) != 2 {
-			fmt.Println("Error: Repository must be in the format 'owner/repo'.")
-			os.Exit(1)
+Several repositories can be given at once; they are resolved, downloaded and installed
+concurrently with --jobs and their output is printed in the order given. Flags describing a single
+asset, such as --checksum or --file-destination, are only accepted with one repository; use a
+manifest with 'nanolayer apply' to configure several.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		slog.Debug("Arguments", "args", args)
+		if len(args) < 1 {
+			return nanolayer.Usagef("GitHub repository argument is required (format: owner/repo)")
 		}
-		assetName := parts[1]
-		if flagAssetName, _ := cmd.Flags().GetString("asset-name"); flagAssetName != "" {
-			assetName = flagAssetName
+		if err := checkSingleRepoFlags(cmd, args); err != nil {
+			return err
 		}
-		fmt.Printf("Using asset name: %s\n", assetName)
 
-		version := "latest"
-		if flagVersion, _ := cmd.Flags().GetString("asset-version"); flagVersion != "" {
-			version = flagVersion
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+
+		if dryRun {
+			return planInstall(cmd, args, jobs)
 		}
-		fmt.Printf("Using version: %s\n", version)
 
-		assetUrlTemplate, _ := cmd.Flags().GetString("asset-url-template")
-		if assetUrlTemplate == "" {
-			assetUrlTemplate = "https://github.com/${Repo}/releases/download/v${Version}/${AssetName}_${Version}_lLinux_${Architecture}.tar.gz"
+		tasks := make([]nanolayer.Task, len(args))
+		for i, repo := range args {
+			tasks[i] = func(out io.Writer) error {
+				opts, err := installOptions(cmd, repo, out, jobs)
+				if err == nil {
+					err = nanolayer.InstallGitHub(cmd.Context(), opts)
+				}
+				if err != nil {
+					nanolayer.NewLogger(out).Error("Installation failed", "event", nanolayer.EventInstall, "repo", repo, "error", err)
+					return err
+				}
+				return nil
+			}
 		}
-		fmt.Printf("Using asset URL template: %s\n", assetUrlTemplate)
-
-		// Parse architecture replacements
-		architectureReplacements := make(map[string]string)
-		archReplacementPairs, _ := cmd.Flags().GetStringArray("architecture-replacement")
-		for _, pair := range archReplacementPairs {
-			parts := strings.Fields(pair)
-			if len(parts) == 2 {
-				architectureReplacements[parts[0]] = parts[1]
+
+		errs := nanolayer.RunParallel(cmd.Context(), tasks, nanolayer.ParallelOptions{Jobs: jobs, FailFast: failFast, Output: os.Stderr})
+		var failure error
+		for i, err := range errs {
+			// Tasks not started after a failure or cancellation print nothing
+			if err == nanolayer.ErrSkipped || (err != nil && err == cmd.Context().Err()) {
+				fmt.Fprintf(os.Stderr, "Skipped %s: %v\n", args[i], err)
+			}
+			// The exit code follows the first failure, not the skips it caused
+			if err != nil && (failure == nil || errors.Is(failure, nanolayer.ErrSkipped)) {
+				failure = fmt.Errorf("installing %s: %w", args[i], err)
 			}
 		}
-		if len(architectureReplacements) > 0 {
-			fmt.Printf("Using architecture replacements: %v\n", architectureReplacements)
+		if failure != nil {
+			return failure
 		}
+		fmt.Println("Installation completed successfully!")
+		return nil
+	},
+}
 
-		// Parse file destinations
-		fileDestinations := make(map[string]string)
-		fileDestPairs, _ := cmd.Flags().GetStringArray("file-destination")
-		if len(fileDestPairs) > 0 {
-			for _, pair := range fileDestPairs {
-				parts := strings.Fields(pair)
-				if len(parts) == 2 {
-					fileDestinations[parts[0]] = parts[1]
-				}
+// planInstall prints what installing args would do without touching the
+// filesystem. Installer messages go to stderr so the plan on stdout can be
+// consumed as JSON.
+func planInstall(cmd *cobra.Command, args []string, jobs int) error {
+	format, _ := cmd.Flags().GetString("output")
+
+	steps := make([]nanolayer.Step, len(args))
+	tasks := make([]nanolayer.Task, len(args))
+	for i, repo := range args {
+		tasks[i] = func(out io.Writer) error {
+			opts, err := installOptions(cmd, repo, out, jobs)
+			if err == nil {
+				steps[i], err = nanolayer.PlanGitHub(cmd.Context(), opts)
+			} else {
+				steps[i] = nanolayer.Step{Installer: "github", Name: repo}
+			}
+			if err != nil {
+				steps[i].Fail(err)
 			}
-		} else {
-			// Use default if no file destinations provided
-			fileDestinations[fmt.Sprintf("*/%s", assetName)] = fmt.Sprintf("/usr/local/bin/%s", assetName)
+			return err
 		}
-		if len(fileDestinations) > 0 {
-			fmt.Printf("Using file destinations: %v\n", fileDestinations)
+	}
+	nanolayer.RunParallel(cmd.Context(), tasks, nanolayer.ParallelOptions{Jobs: jobs, Output: os.Stderr})
+
+	p := &nanolayer.Plan{Steps: steps}
+	if err := p.Write(os.Stdout, format); err != nil {
+		return err
+	}
+	return p.Err()
+}
+
+// singleRepoFlags describe one asset and would be wrong for every other
+// repository if shared
+var singleRepoFlags = []string{"asset-name", "asset-version", "checksum", "file-destination", "verify-command", "expect-version"}
+
+// checkSingleRepoFlags rejects flags of singleRepoFlags when several
+// repositories are given
+func checkSingleRepoFlags(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return nil
+	}
+	for _, name := range singleRepoFlags {
+		if cmd.Flags().Changed(name) {
+			return nanolayer.Usagef("--%s applies to a single repository, install %s separately or use a manifest", name, strings.Join(args, ", "))
 		}
+	}
+	return nil
+}
 
-		err := github.DownloadAndInstallFromAssetUrl(repo,
-			version,
-			assetName,
-			assetUrlTemplate,
-			architectureReplacements,
-			fileDestinations)
-		if err != nil {
-			fmt.Printf("Error during installation: %v\n", err)
-			os.Exit(1)
+// installOptions builds the installer options for a single owner/repo[@version]
+// argument from the command flags
+func installOptions(cmd *cobra.Command, repo string, out io.Writer, jobs int) (nanolayer.GitHubOptions, error) {
+	opts := nanolayer.GitHubOptions{Repo: repo, Output: out}
+	opts.AssetName, _ = cmd.Flags().GetString("asset-name")
+	opts.Version, _ = cmd.Flags().GetString("asset-version")
+	opts.AssetURLTemplate, _ = cmd.Flags().GetString("asset-url-template")
+	opts.Checksum, _ = cmd.Flags().GetString("checksum")
+	opts.Libc, _ = cmd.Flags().GetString("libc")
+	opts.User, _ = cmd.Flags().GetString("user")
+	opts.Scope, _ = cmd.Flags().GetString("scope")
+	opts.AddToPath, _ = cmd.Flags().GetBool("add-to-path")
+	opts.WithCompletions, _ = cmd.Flags().GetBool("with-completions")
+	opts.WithManpages, _ = cmd.Flags().GetBool("with-manpages")
+	opts.VerifyCommand, _ = cmd.Flags().GetString("verify-command")
+	opts.ExpectVersion, _ = cmd.Flags().GetString("expect-version")
+	if jobs > 1 {
+		// Keep the progress of concurrent downloads with their output
+		opts.Progress = out
+	}
+
+	replacementPairs, _ := cmd.Flags().GetStringArray("architecture-replacement")
+	opts.ArchitectureReplacements = parsePairs(replacementPairs)
+	destinationPairs, _ := cmd.Flags().GetStringArray("file-destination")
+	opts.FileDestinations = parsePairs(destinationPairs)
+
+	if value, _ := cmd.Flags().GetString("target-arch"); value != "" {
+		architecture, ok := nanolayer.ParseArchitecture(value)
+		if !ok {
+			return opts, nanolayer.Usagef("unknown target architecture %q", value)
 		}
-		fmt.Println("Installation completed successfully!")
+		opts.Architecture = architecture
+	}
+	return opts, nil
+}
 
-	},
+// parsePairs parses "key value" flag values into a map, ignoring malformed ones
+func parsePairs(pairs []string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range pairs {
+		parts := strings.Fields(pair)
+		if len(parts) == 2 {
+			result[parts[0]] = parts[1]
+		}
+	}
+	return result
 }
 
 func init() {
-	GithubCmd.Flags().String("asset-url-template", "", "Custom asset URL template (e.g., https://github.com/${Repo}/releases/download/v${Version}/${AssetName}_${Version}_Linux_${Architecture}.tar.gz)")
+	GithubCmd.Flags().String("asset-url-template", "", "Custom asset URL template using ${Key} placeholders or Go template syntax, with keys Repo, Version, AssetName, Architecture, GoArch, DebianArch, RustTarget, DockerPlatform, OS, Distro and Libc (e.g., https://github.com/${Repo}/releases/download/v${Version}/${AssetName}_${Version}_Linux_${Architecture}.tar.gz or .../{{ .AssetName }}_{{ .Version | trimPrefix \"v\" }}_{{ .OS | title }}.tar.gz)")
 	GithubCmd.Flags().String("asset-name", "", "Override the asset name derived from the repository (e.g., --asset-name gum)")
 	GithubCmd.Flags().String("asset-version", "", "Override the version used when fetching the asset (e.g., --asset-version 1.10.3)")
 	GithubCmd.Flags().StringArray("architecture-replacement", []string{}, "Architecture replacement pairs (e.g., --architecture-replacement 'arm64 aarch64' --architecture-replacement 'amd64 intel')")
-	GithubCmd.Flags().StringArray("file-destination", []string{}, "File destination mappings (e.g., --file-destination '*/gum /usr/local/bin/gum')")
+	GithubCmd.Flags().String("checksum", "", "Expected SHA-256 of the downloaded asset (e.g., --checksum sha256:3b1f...)")
+	GithubCmd.Flags().String("libc", "", "Override the detected libc used for ${Libc} in the asset URL template: gnu or musl")
+	GithubCmd.Flags().String("target-arch", "", "Install the asset for this architecture instead of the detected one, as a canonical, Go, Debian, Rust or Docker name (e.g., --target-arch linux/arm64)")
+	GithubCmd.Flags().String("user", "", "Install for this user, defaults to $_REMOTE_USER, $_CONTAINER_USER, $SUDO_USER or the current user")
+	GithubCmd.Flags().String("scope", "", "Install system-wide below /usr/local (system) or below ~/.local of the user (user), defaults to system with root privileges and user without")
+	GithubCmd.Flags().Bool("add-to-path", false, "Add the directories of the installed files to PATH in the shell startup files (see 'nanolayer env')")
+	GithubCmd.Flags().Bool("with-completions", false, "Install bash, zsh and fish completions found in the archive, or generate them with '<binary> completion <shell>'")
+	GithubCmd.Flags().Bool("with-manpages", false, "Install man pages found in the archive to /usr/local/share/man")
+	GithubCmd.Flags().String("verify-command", "", "Command run against the downloaded files before installing them, with {bin} replaced by the binary (e.g., --verify-command '{bin} --version')")
+	GithubCmd.Flags().String("expect-version", "", "Version that must appear in the output of --verify-command, which defaults to '{bin} --version'")
+	GithubCmd.Flags().StringArray("file-destination", []string{}, "File destination mappings, patterns may start with elf: or script: to match only binaries for the target architecture or scripts (e.g., --file-destination 'elf:*/gum /usr/local/bin/gum')")
+	GithubCmd.Flags().IntP("jobs", "j", 1, "Number of repositories to install concurrently")
+	GithubCmd.Flags().Bool("fail-fast", false, "Stop installing further repositories after the first failure")
+	GithubCmd.Flags().Bool("dry-run", false, "Resolve versions and asset URLs and print the files that would be written, without installing anything")
+	GithubCmd.Flags().StringP("output", "o", "text", "Format of the --dry-run plan: text or json")
 }
@@ -0,0 +1,44 @@
+package github
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// newTestCommand returns a command with the flags of GithubCmd, setting
+// name/value pairs of flags
+func newTestCommand(t *testing.T, flags ...string) *cobra.Command {
+	cmd := &cobra.Command{}
+	GithubCmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		cmd.Flags().String(flag.Name, "", flag.Usage)
+	})
+	for i := 0; i < len(flags); i += 2 {
+		if err := cmd.Flags().Set(flags[i], flags[i+1]); err != nil {
+			t.Fatalf("failed to set --%s: %v", flags[i], err)
+		}
+	}
+	return cmd
+}
+
+func TestCheckSingleRepoFlagsRejectsSharedAssetFlags(t *testing.T) {
+	for _, name := range singleRepoFlags {
+		cmd := newTestCommand(t, name, "value")
+		if err := checkSingleRepoFlags(cmd, []string{"junegunn/fzf"}); err != nil {
+			t.Fatalf("--%s: unexpected error for a single repository: %v", name, err)
+		}
+		err := checkSingleRepoFlags(cmd, []string{"junegunn/fzf", "charmbracelet/gum"})
+		var usage *nanolayer.UsageError
+		if !errors.As(err, &usage) {
+			t.Fatalf("--%s: expected a usage error for several repositories, got %v", name, err)
+		}
+	}
+
+	cmd := newTestCommand(t, "libc", "musl", "target-arch", "arm64")
+	if err := checkSingleRepoFlags(cmd, []string{"junegunn/fzf", "charmbracelet/gum"}); err != nil {
+		t.Fatalf("unexpected error for flags shared by all repositories: %v", err)
+	}
+}
@@ -13,9 +13,9 @@ var InstallCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install packages and tools",
 	Long:  `Install various packages and tools from different sources.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no subcommand is provided, show help
-		cmd.Help()
+		return cmd.Help()
 	},
 }
 
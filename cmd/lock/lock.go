@@ -0,0 +1,43 @@
+package lock
+
+import (
+	"fmt"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+	"github.com/spf13/cobra"
+)
+
+var LockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Resolve a manifest into a reproducible nanolayer.lock",
+	Long: `Resolve every tool in a nanolayer.yaml manifest to an exact version, asset URL and SHA-256 per
+architecture and write them to nanolayer.lock. Use 'nanolayer apply --locked' to install from it.
+Packages are not locked; --locked requires them to be pinned in the manifest as name=version.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := nanolayer.LockOptions{}
+		opts.File, _ = cmd.Flags().GetString("file")
+		opts.LockFile, _ = cmd.Flags().GetString("output")
+
+		values, _ := cmd.Flags().GetStringArray("architecture")
+		for _, value := range values {
+			architecture, ok := nanolayer.ParseArchitecture(value)
+			if !ok {
+				return nanolayer.Usagef("unknown architecture %q", value)
+			}
+			opts.Architectures = append(opts.Architectures, architecture)
+		}
+
+		path, err := nanolayer.Lock(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return nil
+	},
+}
+
+func init() {
+	LockCmd.Flags().StringP("file", "f", nanolayer.DefaultManifest, "Path to the manifest file")
+	LockCmd.Flags().StringP("output", "o", "", "Path to the lock file (defaults to nanolayer.lock next to the manifest)")
+	LockCmd.Flags().StringArray("architecture", []string{}, "Architecture to lock, repeatable, as a canonical, Go, Debian, Rust or Docker name (defaults to x86_64 and arm64)")
+}
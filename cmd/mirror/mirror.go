@@ -0,0 +1,63 @@
+package mirror
+
+import (
+	"fmt"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+	"github.com/spf13/cobra"
+)
+
+var MirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Prepare offline mirrors of release downloads",
+	Long:  `Commands for pre-populating a directory that can be served as an internal HTTP mirror.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// If no subcommand is provided, show help
+		return cmd.Help()
+	},
+}
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [owner/repo[@version]...]",
+	Short: "Download release metadata and assets into a mirror directory",
+	Long: `Download GitHub release metadata and assets for a list of tools into a directory laid out
+as <dir>/<host>/<path>. Serve the directory with any static file server and replay installs with
+--mirror https://api.github.com=<server>/api.github.com --mirror https://github.com=<server>/github.com.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return nanolayer.Usagef("at least one GitHub repository is required (format: owner/repo[@version])")
+		}
+
+		dir, _ := cmd.Flags().GetString("dir")
+		assetPatterns, _ := cmd.Flags().GetStringArray("asset-pattern")
+
+		for _, arg := range args {
+			repo, version, err := nanolayer.ParseRepository(arg)
+			if err != nil {
+				return &nanolayer.UsageError{Err: err}
+			}
+			if version == "" {
+				version = "latest"
+			}
+
+			fmt.Printf("Mirroring %s (%s)\n", repo, version)
+			written, err := nanolayer.Mirror(cmd.Context(), nanolayer.MirrorOptions{Repo: arg, AssetPatterns: assetPatterns, Dir: dir})
+			for _, path := range written {
+				fmt.Printf("  %s\n", path)
+			}
+			if err != nil {
+				return fmt.Errorf("mirroring %s: %w", repo, err)
+			}
+		}
+
+		fmt.Println("Mirror completed successfully!")
+		return nil
+	},
+}
+
+func init() {
+	fetchCmd.Flags().String("dir", "mirror", "Directory to populate with mirrored files")
+	fetchCmd.Flags().StringArray("asset-pattern", []string{}, "Only mirror assets whose names match this glob (e.g., --asset-pattern '*_Linux_*.tar.gz')")
+
+	MirrorCmd.AddCommand(fetchCmd)
+}
@@ -1,38 +1,152 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
+	"github.com/devcontainer-community/nanolayer-go/cmd/apply"
+	"github.com/devcontainer-community/nanolayer-go/cmd/cache"
+	"github.com/devcontainer-community/nanolayer-go/cmd/env"
 	"github.com/devcontainer-community/nanolayer-go/cmd/install"
+	"github.com/devcontainer-community/nanolayer-go/cmd/lock"
+	"github.com/devcontainer-community/nanolayer-go/cmd/mirror"
 	"github.com/devcontainer-community/nanolayer-go/cmd/system"
-	"github.com/devcontainer-community/nanolayer-go/internal"
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "nanolayer",
 	Short: "Nanolayer - A developer container tool",
 	Long:  `Nanolayer is a tool for working with development containers.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	// Errors are printed by Execute, which also picks the exit code
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// Check for version flag
 		versionFlag, _ := cmd.Flags().GetBool("version")
 		if versionFlag {
-			fmt.Printf("nanolayer version %s\n", internal.Version)
-			fmt.Printf("commit: %s\n", internal.Commit)
-			fmt.Printf("built at: %s\n", internal.Date)
-			return
+			printVersion()
+			return nil
 		}
 		// If no subcommand is provided, show help
-		cmd.Help()
+		return cmd.Help()
 	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := configureLogging(cmd); err != nil {
+			return &nanolayer.UsageError{Err: err}
+		}
+		configureTimeout(cmd)
+		return configure(cmd)
+	},
+}
+
+// configureLogging sets up the diagnostics on stderr from --verbose, --quiet
+// and --log-format
+func configureLogging(cmd *cobra.Command) error {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	format, _ := cmd.Flags().GetString("log-format")
+	return nanolayer.ConfigureLogging(format, verbose, quiet)
+}
+
+// cancelTimeout releases the --timeout context once the command has finished
+var cancelTimeout context.CancelFunc = func() {}
+
+// configureTimeout limits the whole command to --timeout
+func configureTimeout(cmd *cobra.Command) {
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		cancelTimeout = cancel
+		cmd.SetContext(ctx)
+	}
+}
+
+// configure applies the global download, TLS, timeout and mirror flags to
+// the installers
+func configure(cmd *cobra.Command) error {
+	cfg := nanolayer.DefaultConfig()
+	if cacheDir, _ := cmd.Flags().GetString("cache-dir"); cacheDir != "" {
+		cfg.CacheDir = cacheDir
+	}
+	if offline, _ := cmd.Flags().GetBool("offline"); offline {
+		cfg.Offline = true
+	}
+	if retries, _ := cmd.Flags().GetInt("download-retries"); cmd.Flags().Changed("download-retries") {
+		cfg.Retries = retries
+	}
+	cfg.Chunks, _ = cmd.Flags().GetInt("download-chunks")
+	if quiet, _ := cmd.Flags().GetBool("quiet"); !quiet {
+		cfg.Progress = os.Stderr
+	}
+
+	cfg.HTTPTimeout, _ = cmd.Flags().GetDuration("http-timeout")
+	cfg.CACertFile, _ = cmd.Flags().GetString("ca-cert")
+	cfg.ClientCertFile, _ = cmd.Flags().GetString("client-cert")
+	cfg.ClientKeyFile, _ = cmd.Flags().GetString("client-key")
+	cfg.InsecureSkipVerify, _ = cmd.Flags().GetBool("insecure")
+
+	if configPath, _ := cmd.Flags().GetString("mirror-config"); configPath != "" {
+		cfg.MirrorConfig = configPath
+	}
+	cfg.Mirrors, _ = cmd.Flags().GetStringArray("mirror")
+	return nanolayer.Configure(cfg)
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// SIGINT and SIGTERM cancel the context, which aborts downloads and
+	// terminates package managers so their cleanup and restore steps run
+	// before nanolayer exits. A second signal exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	usageErrors(rootCmd)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	cancelTimeout()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var usage *nanolayer.UsageError
+		if errors.As(err, &usage) {
+			fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
+		os.Exit(nanolayer.ExitCode(err))
+	}
+}
+
+// usageErrors marks invalid flags, arguments and subcommands of cmd and its
+// subcommands as usage errors, so they exit with nanolayer.ExitUsage
+func usageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &nanolayer.UsageError{Err: err}
+	})
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return &nanolayer.UsageError{Err: err}
+			}
+			return nil
+		}
+	} else if !cmd.HasParent() {
+		// cobra reports unknown subcommands of the root through its default
+		// argument validation, which is kept but marked
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return &nanolayer.UsageError{Err: err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		usageErrors(sub)
 	}
 }
 
@@ -40,4 +154,25 @@ func init() {
 	// Global flags can be added here
 	rootCmd.AddCommand(install.InstallCmd)
 	rootCmd.AddCommand(system.SystemCmd)
+	rootCmd.AddCommand(cache.CacheCmd)
+	rootCmd.AddCommand(mirror.MirrorCmd)
+	rootCmd.AddCommand(apply.ApplyCmd)
+	rootCmd.AddCommand(lock.LockCmd)
+	rootCmd.AddCommand(env.EnvCmd)
+
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Log debug messages, such as the settings used and the files found in archives")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Suppress progress output and log only warnings and errors")
+	rootCmd.PersistentFlags().String("log-format", "text", "Format of the log messages on stderr: text or json")
+	rootCmd.PersistentFlags().String("cache-dir", "", "Directory for the shared download cache (defaults to $NANOLAYER_CACHE_DIR, disabled if unset)")
+	rootCmd.PersistentFlags().Bool("offline", false, "Only use the download cache and never access the network")
+	rootCmd.PersistentFlags().String("ca-cert", "", "PEM bundle of additional trusted CA certificates (defaults to $NANOLAYER_CA_BUNDLE)")
+	rootCmd.PersistentFlags().String("client-cert", "", "PEM client certificate for TLS client authentication")
+	rootCmd.PersistentFlags().String("client-key", "", "PEM private key for the client certificate")
+	rootCmd.PersistentFlags().Bool("insecure", false, "Skip TLS certificate verification (not recommended)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort the whole command after this duration (e.g., --timeout 10m, no limit if 0)")
+	rootCmd.PersistentFlags().Duration("http-timeout", 0, "Timeout for each HTTP request (e.g., --http-timeout 5m, no limit if 0)")
+	rootCmd.PersistentFlags().Int("download-retries", 3, "Number of times an interrupted download is resumed")
+	rootCmd.PersistentFlags().Int("download-chunks", 1, "Download large files as this many parallel ranges when the server supports it")
+	rootCmd.PersistentFlags().StringArray("mirror", []string{}, "URL prefix rewrite for all downloads (e.g., --mirror https://github.com=https://mirror.local/gh)")
+	rootCmd.PersistentFlags().String("mirror-config", "", "File of 'prefix=replacement' URL rewrites, one per line (defaults to $NANOLAYER_MIRROR_CONFIG)")
 }
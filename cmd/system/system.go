@@ -2,27 +2,49 @@ package system
 
 import (
 	"fmt"
+	"os"
 
-	"github.com/devcontainer-community/nanolayer-go/internal"
-	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
 	"github.com/spf13/cobra"
 )
 
 var SystemCmd = &cobra.Command{
 	Use:   "system",
 	Short: "System-related commands",
-	Long:  `Commands for system information and operations.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("IsLinux=%t\n", linuxsystem.IsLinux())
-		fmt.Printf("Architecture=%s\n", linuxsystem.GetArchitecture())
-		fmt.Printf("Distribution=%s\n", linuxsystem.GetDistribution())
-		fmt.Printf("HasRootPrivileges=%t\n", linuxsystem.HasRootPrivileges())
-		fmt.Printf("NanolayerVersion=%s\n", internal.Version)
-		fmt.Printf("NanolayerCommit=%s\n", internal.Commit)
-		fmt.Printf("NanolayerDate=%s\n", internal.Date)
+	Long: `Commands for system information and operations.
+
+Without a subcommand the detected system facts are printed as Key=value lines,
+or with --output as json, env (NANOLAYER_KEY=value) or shell (export statements).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		facts, err := nanolayer.Facts(cmd.Context())
+		if err != nil {
+			return err
+		}
+		return nanolayer.WriteFacts(os.Stdout, facts, format)
+	},
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a single system fact",
+	Long:  `Print the value of a single system fact, e.g. "nanolayer system get Architecture". Keys are case-insensitive.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		facts, err := nanolayer.Facts(cmd.Context())
+		if err != nil {
+			return err
+		}
+		fact, ok := nanolayer.LookupFact(facts, args[0])
+		if !ok {
+			return nanolayer.Usagef("unknown system fact %q", args[0])
+		}
+		fmt.Println(nanolayer.FormatValue(fact.Value))
+		return nil
 	},
 }
 
 func init() {
-	// Add subcommands here if needed
+	SystemCmd.Flags().StringP("output", "o", "text", "Output format: text, json, env or shell")
+	SystemCmd.AddCommand(getCmd)
 }
@@ -3,7 +3,7 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/devcontainer-community/nanolayer-go/internal"
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
 	"github.com/spf13/cobra"
 )
 
@@ -12,13 +12,19 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version information",
 	Long:  `Display the version, commit hash, and build date of nanolayer.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("nanolayer version %s\n", internal.Version)
-		fmt.Printf("commit: %s\n", internal.Commit)
-		fmt.Printf("built at: %s\n", internal.Date)
+		printVersion()
 	},
 }
 
+// printVersion prints the version, commit and build date
+func printVersion() {
+	build := nanolayer.Build()
+	fmt.Printf("nanolayer version %s\n", build.Version)
+	fmt.Printf("commit: %s\n", build.Commit)
+	fmt.Printf("built at: %s\n", build.Date)
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
-	rootCmd.PersistentFlags().BoolP("version", "v", false, "Print version information")
+	rootCmd.PersistentFlags().BoolP("version", "V", false, "Print version information")
 }
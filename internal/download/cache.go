@@ -0,0 +1,379 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+)
+
+// CACHE_DIR_ENV is the environment variable used to enable the download cache
+const CACHE_DIR_ENV = "NANOLAYER_CACHE_DIR"
+
+// Cache is a content-addressed store of downloaded assets.
+//
+// Blobs are stored by the SHA-256 of their content under blobs/sha256, while
+// entries under entries/ map a request key (URL and expected checksum) to a
+// blob together with the validators needed to revalidate it.
+type Cache struct {
+	Dir string
+}
+
+// Entry describes a single cached download
+type Entry struct {
+	Key          string    `json:"key"`
+	URL          string    `json:"url"`
+	Checksum     string    `json:"checksum,omitempty"`
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	LastUsed     time.Time `json:"last_used"`
+}
+
+// NewCache returns a cache rooted at dir, or nil when dir is empty
+func NewCache(dir string) *Cache {
+	if dir == "" {
+		return nil
+	}
+	return &Cache{Dir: dir}
+}
+
+// CacheKey returns the key used to index a download by URL and checksum
+func CacheKey(url string, checksum string) string {
+	sum := sha256.Sum256([]byte(url + "\n" + normalizeChecksum(checksum)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) entriesDir() string {
+	return filepath.Join(c.Dir, "entries")
+}
+
+func (c *Cache) blobsDir() string {
+	return filepath.Join(c.Dir, "blobs", "sha256")
+}
+
+func (c *Cache) partialDir() string {
+	return filepath.Join(c.Dir, "partial")
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.entriesDir(), key+".json")
+}
+
+func (c *Cache) blobPath(digest string) string {
+	return filepath.Join(c.blobsDir(), digest)
+}
+
+// errCorrupt reports a cache entry or blob that was removed because its
+// content is damaged, so the download has to be fetched again
+var errCorrupt = errors.New("corrupt cache entry")
+
+// Lookup returns the entry stored for url and checksum, if any. Entries that
+// cannot be parsed or reference an invalid blob are removed and treated as a
+// miss, so a damaged shared cache does not break later runs.
+func (c *Cache) Lookup(url string, checksum string) (*Entry, error) {
+	key := CacheKey(url, checksum)
+	data, err := os.ReadFile(c.entryPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	entry, err := parseEntry(data)
+	if err == nil && entry.Key != key {
+		err = fmt.Errorf("entry is stored under key %s", key)
+	}
+	if err != nil {
+		c.drop(key, "", err)
+		return nil, nil
+	}
+
+	// An entry whose blob has been removed is treated as a miss
+	if _, err := os.Stat(c.blobPath(entry.SHA256)); err != nil {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// parseEntry decodes an entry and checks that its key and digest can be used
+// as file names inside the cache
+func parseEntry(data []byte) (*Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if !isDigest(entry.SHA256) || !isDigest(entry.Key) {
+		return nil, fmt.Errorf("invalid key %q or sha256 %q", entry.Key, entry.SHA256)
+	}
+	return &entry, nil
+}
+
+// drop removes a damaged entry, and its blob when given, logging why
+func (c *Cache) drop(key string, digest string, reason error) {
+	logging.Event(slog.Default(), logging.EventDownload).Warn("Removing corrupt cache entry", "key", key, "error", reason)
+	os.Remove(c.entryPath(key))
+	if digest != "" {
+		os.Remove(c.blobPath(digest))
+	}
+}
+
+// Read returns the content of the blob referenced by entry. A blob whose
+// content does not match its digest is removed along with the entry and
+// reported as errCorrupt.
+func (c *Cache) Read(entry *Entry) ([]byte, error) {
+	data, err := os.ReadFile(c.blobPath(entry.SHA256))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached blob: %w", err)
+	}
+	if digest := sha256Hex(data); digest != entry.SHA256 {
+		err := fmt.Errorf("%w: blob %s has sha256 %s", errCorrupt, entry.SHA256, digest)
+		c.drop(entry.Key, entry.SHA256, err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// Store writes content to the cache and records an entry for it
+func (c *Cache) Store(entry Entry, content []byte) (*Entry, error) {
+	if err := os.MkdirAll(c.blobsDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.MkdirAll(c.entriesDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	entry.Key = CacheKey(entry.URL, entry.Checksum)
+	entry.Checksum = normalizeChecksum(entry.Checksum)
+	entry.SHA256 = sha256Hex(content)
+	entry.Size = int64(len(content))
+
+	blob := c.blobPath(entry.SHA256)
+	if _, err := os.Stat(blob); err != nil {
+		if err := writeFileAtomic(blob, content); err != nil {
+			return nil, fmt.Errorf("failed to write cached blob: %w", err)
+		}
+	}
+
+	if err := c.writeEntry(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Touch updates the last used time of an entry
+func (c *Cache) Touch(entry *Entry) error {
+	entry.LastUsed = time.Now().UTC()
+	return c.writeEntry(entry)
+}
+
+func (c *Cache) writeEntry(entry *Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := writeFileAtomic(c.entryPath(entry.Key), data); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// isDigest reports whether name is a hex SHA-256 digest, as used for blob
+// names and entry keys
+func isDigest(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil && strings.ToLower(name) == name
+}
+
+// List returns all cache entries ordered by URL, skipping and logging
+// entries that cannot be read or whose digest or key is malformed
+func (c *Cache) List() ([]Entry, error) {
+	files, err := os.ReadDir(c.entriesDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache entries: %w", err)
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.entriesDir(), file.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			// Removed since listing the directory
+			continue
+		}
+		if err == nil {
+			var entry *Entry
+			if entry, err = parseEntry(data); err == nil {
+				entries = append(entries, *entry)
+				continue
+			}
+		}
+		logging.Event(slog.Default(), logging.EventDownload).Warn("Skipping unreadable cache entry", "file", file.Name(), "error", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].URL == entries[j].URL {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].URL < entries[j].URL
+	})
+	return entries, nil
+}
+
+// Prune removes entries not used since olderThan ago along with any blobs no
+// longer referenced by an entry and partial downloads not written to since
+// then. It returns the number of bytes freed.
+func (c *Cache) Prune(olderThan time.Duration) (int64, error) {
+	entries, err := c.List()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().UTC().Add(-olderThan)
+	freed, err := c.prunePartials(cutoff)
+	if err != nil {
+		return freed, err
+	}
+	referenced := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.LastUsed.Before(cutoff) {
+			if err := os.Remove(c.entryPath(entry.Key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return 0, fmt.Errorf("failed to remove cache entry: %w", err)
+			}
+			continue
+		}
+		referenced[entry.SHA256] = true
+	}
+
+	blobs, err := os.ReadDir(c.blobsDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return freed, nil
+	}
+	if err != nil {
+		return freed, fmt.Errorf("failed to list cached blobs: %w", err)
+	}
+
+	for _, blob := range blobs {
+		// Temporary files of downloads in progress are not digests
+		if referenced[blob.Name()] || !isDigest(blob.Name()) {
+			continue
+		}
+		if info, err := blob.Info(); err == nil {
+			freed += info.Size()
+		}
+		if err := os.Remove(c.blobPath(blob.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return freed, fmt.Errorf("failed to remove cached blob: %w", err)
+		}
+	}
+	return freed, nil
+}
+
+// prunePartials removes the partial downloads, with their validator and lock
+// files, of keys whose files were all last modified before cutoff
+func (c *Cache) prunePartials(cutoff time.Time) (int64, error) {
+	files, err := os.ReadDir(c.partialDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list partial downloads: %w", err)
+	}
+
+	type partial struct {
+		names    []string
+		size     int64
+		modified time.Time
+	}
+	keys := make(map[string]*partial)
+	for _, file := range files {
+		key, _, _ := strings.Cut(file.Name(), ".")
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		if keys[key] == nil {
+			keys[key] = &partial{}
+		}
+		p := keys[key]
+		p.names = append(p.names, file.Name())
+		p.size += info.Size()
+		if info.ModTime().After(p.modified) {
+			p.modified = info.ModTime()
+		}
+	}
+
+	var freed int64
+	for _, p := range keys {
+		if !p.modified.Before(cutoff) {
+			continue
+		}
+		for _, name := range p.names {
+			if err := os.Remove(filepath.Join(c.partialDir(), name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return freed, fmt.Errorf("failed to remove partial download: %w", err)
+			}
+		}
+		freed += p.size
+	}
+	return freed, nil
+}
+
+// Clear removes every entry, blob and partial download from the cache
+func (c *Cache) Clear() error {
+	for _, dir := range []string{c.entriesDir(), filepath.Join(c.Dir, "blobs"), c.partialDir()} {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeChecksum lowercases a checksum and strips an optional sha256: prefix
+func normalizeChecksum(checksum string) string {
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	return strings.TrimPrefix(checksum, "sha256:")
+}
@@ -0,0 +1,200 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/httpclient"
+	"github.com/devcontainer-community/nanolayer-go/internal/progress"
+)
+
+// OFFLINE_ENV is the environment variable used to enable offline mode
+const OFFLINE_ENV = "NANOLAYER_OFFLINE"
+
+// ErrChecksumMismatch is returned when downloaded content does not match the
+// expected SHA-256
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrNotFound is returned when the server has no file at the URL
+var ErrNotFound = errors.New("not found")
+
+// ErrNotCached is returned in offline mode for URLs missing from the cache
+var ErrNotCached = errors.New("not in the download cache")
+
+// Downloader fetches assets over HTTP, optionally through a Cache
+type Downloader struct {
+	// Client is the HTTP client used for requests, httpclient.Client() if nil
+	Client *http.Client
+	// Cache stores downloads for reuse across runs, disabled if nil
+	Cache *Cache
+	// Offline restricts Fetch to the cache and never touches the network
+	Offline bool
+	// Progress receives progress events while downloading, disabled if nil
+	Progress progress.Reporter
+	// Retries is the number of times an interrupted transfer is resumed
+	Retries int
+	// RetryDelay is the pause before the first retry, doubled for each further retry
+	RetryDelay time.Duration
+	// Chunks is the number of parallel range requests used when the server
+	// supports them, a single stream is used if less than 2
+	Chunks int
+	// MinChunkSize is the smallest range fetched by a parallel chunk
+	MinChunkSize int64
+	// ReadOnly keeps downloads in memory and never writes to the cache or
+	// to partial files, so a dry run leaves the filesystem untouched
+	ReadOnly bool
+}
+
+// Default is the downloader used by the installers
+var Default = &Downloader{
+	Cache:        NewCache(os.Getenv(CACHE_DIR_ENV)),
+	Offline:      os.Getenv(OFFLINE_ENV) == "1" || os.Getenv(OFFLINE_ENV) == "true",
+	Retries:      3,
+	RetryDelay:   time.Second,
+	MinChunkSize: 4 * 1024 * 1024,
+}
+
+// WithProgressOutput returns a copy of d reporting progress as plain lines to
+// w, or without progress when d has none. It lets concurrent downloads keep
+// their progress apart.
+func (d *Downloader) WithProgressOutput(w io.Writer) *Downloader {
+	copied := *d
+	if _, quiet := d.Progress.(progress.Nop); d.Progress != nil && !quiet {
+		copied.Progress = progress.New(w, false)
+	}
+	return &copied
+}
+
+// WithReadOnly returns a copy of d that never writes to the filesystem
+func (d *Downloader) WithReadOnly() *Downloader {
+	copied := *d
+	copied.ReadOnly = true
+	return &copied
+}
+
+// Fetch downloads url and returns its content.
+//
+// If checksum is non-empty (hex SHA-256, optionally prefixed with "sha256:")
+// the content is verified against it. When a cache is configured, a cached
+// copy is revalidated with ETag/Last-Modified and reused if unchanged; in
+// offline mode the cached copy is returned without contacting the server.
+// Canceling ctx aborts the transfer and keeps the partial file to resume.
+func (d *Downloader) Fetch(ctx context.Context, url string, checksum string) ([]byte, error) {
+	var cached *Entry
+	if d.Cache != nil {
+		entry, err := d.Cache.Lookup(url, checksum)
+		if err != nil {
+			return nil, err
+		}
+		cached = entry
+	}
+
+	// A checksum pins the content, so a cached copy never needs revalidation
+	if cached != nil && (d.Offline || checksum != "") {
+		content, err := d.readCached(cached)
+		if !errors.Is(err, errCorrupt) {
+			return content, err
+		}
+		cached = nil
+	}
+	if d.Offline {
+		return nil, fmt.Errorf("offline mode: %s is %w", url, ErrNotCached)
+	}
+
+	result, err := d.fetchRemote(ctx, url, checksum, cached)
+	if err != nil {
+		return nil, err
+	}
+	if result.notModified {
+		content, err := d.readCached(cached)
+		if !errors.Is(err, errCorrupt) {
+			return content, err
+		}
+		// The server confirmed a copy that turned out to be damaged
+		if result, err = d.fetchRemote(ctx, url, checksum, nil); err != nil {
+			return nil, err
+		}
+	}
+	content := result.content
+
+	if err := VerifyChecksum(content, checksum); err != nil {
+		return nil, err
+	}
+
+	if d.Cache != nil && !d.ReadOnly {
+		now := time.Now().UTC()
+		_, err := d.Cache.Store(Entry{
+			URL:          url,
+			Checksum:     checksum,
+			ETag:         result.etag,
+			LastModified: result.lastModified,
+			FetchedAt:    now,
+			LastUsed:     now,
+		}, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return content, nil
+}
+
+// fetchRemote downloads url, revalidating cached when it is not nil
+func (d *Downloader) fetchRemote(ctx context.Context, url string, checksum string, cached *Entry) (*transferResult, error) {
+	if d.ReadOnly {
+		return d.transferInMemory(ctx, url, cached)
+	}
+	return d.transfer(ctx, url, CacheKey(url, checksum), cached)
+}
+
+func (d *Downloader) readCached(entry *Entry) ([]byte, error) {
+	content, err := d.Cache.Read(entry)
+	if err != nil {
+		return nil, err
+	}
+	if d.ReadOnly {
+		return content, nil
+	}
+	if err := d.Cache.Touch(entry); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// partialPath returns where the cache keeps an in-progress download for key,
+// so it can be resumed by a later run
+func (d *Downloader) partialPath(key string) string {
+	return filepath.Join(d.partialDir(), key+".partial")
+}
+
+func (d *Downloader) reporter() progress.Reporter {
+	if d.Progress != nil {
+		return d.Progress
+	}
+	return progress.Nop{}
+}
+
+func (d *Downloader) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return httpclient.Client()
+}
+
+// VerifyChecksum checks content against a SHA-256 checksum, if one is given
+func VerifyChecksum(content []byte, checksum string) error {
+	want := normalizeChecksum(checksum)
+	if want == "" {
+		return nil
+	}
+	if got := sha256Hex(content); got != want {
+		return fmt.Errorf("%w: expected sha256 %s, got %s", ErrChecksumMismatch, want, got)
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchWithoutCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	d := &Downloader{}
+	got, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", "")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if string(got) != "payload" {
+		t.Fatalf("unexpected content: %q", string(got))
+	}
+}
+
+func TestFetchRevalidatesWithETag(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	for i := 0; i < 2; i++ {
+		got, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", "")
+		if err != nil {
+			t.Fatalf("Fetch #%d returned error: %v", i, err)
+		}
+		if string(got) != "payload" {
+			t.Fatalf("Fetch #%d unexpected content: %q", i, string(got))
+		}
+	}
+
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("expected 2 requests with 1 revalidation, got %d requests and %d revalidations", requests, notModified)
+	}
+}
+
+func TestFetchWithChecksumSkipsNetworkWhenCached(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	checksum := "sha256:" + sha256Hex([]byte("payload"))
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	for i := 0; i < 2; i++ {
+		if _, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", checksum); err != nil {
+			t.Fatalf("Fetch #%d returned error: %v", i, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected a single request, got %d", requests)
+	}
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	_, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", strings.Repeat("0", 64))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected checksum mismatch error, got %v", err)
+	}
+
+	entries, err := d.Cache.List()
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected mismatching download not to be cached, got %d entries", len(entries))
+	}
+}
+
+func TestFetchOffline(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	if _, err := cache.Store(Entry{URL: "https://example.com/tool.tar.gz"}, []byte("payload")); err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+
+	d := &Downloader{Cache: cache, Offline: true}
+	got, err := d.Fetch(context.Background(), "https://example.com/tool.tar.gz", "")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if string(got) != "payload" {
+		t.Fatalf("unexpected content: %q", string(got))
+	}
+
+	if _, err := d.Fetch(context.Background(), "https://example.com/other.tar.gz", ""); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("expected error for cache miss in offline mode")
+	}
+}
+
+func TestCachePruneAndClear(t *testing.T) {
+	cache := NewCache(t.TempDir())
+
+	old, err := cache.Store(Entry{URL: "https://example.com/old"}, []byte("old"))
+	if err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+	old.LastUsed = time.Now().Add(-48 * time.Hour)
+	if err := cache.writeEntry(old); err != nil {
+		t.Fatalf("writeEntry returned error: %v", err)
+	}
+	if _, err := cache.Store(Entry{URL: "https://example.com/new", LastUsed: time.Now()}, []byte("new")); err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+
+	freed, err := cache.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if freed != int64(len("old")) {
+		t.Fatalf("expected %d bytes freed, got %d", len("old"), freed)
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].URL != "https://example.com/new" {
+		t.Fatalf("unexpected entries after prune: %+v", entries)
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	entries, err = cache.List()
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty cache after clear, got %d entries", len(entries))
+	}
+}
+
+func TestFetchReportsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "7")
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	recorder := &recordingReporter{}
+	d := &Downloader{Progress: recorder}
+	if _, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", ""); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+
+	if recorder.name != "tool.tar.gz" || recorder.total != 7 {
+		t.Fatalf("unexpected start event: name=%q total=%d", recorder.name, recorder.total)
+	}
+	if recorder.current != 7 || !recorder.finished {
+		t.Fatalf("expected finished transfer of 7 bytes, got current=%d finished=%v", recorder.current, recorder.finished)
+	}
+}
+
+type recordingReporter struct {
+	name     string
+	total    int64
+	current  int64
+	finished bool
+}
+
+func (r *recordingReporter) Start(name string, total int64) { r.name, r.total = name, total }
+func (r *recordingReporter) Update(current int64)           { r.current = current }
+func (r *recordingReporter) Finish(err error)               { r.finished = err == nil }
+
+func TestFetchReadOnlyLeavesCacheUntouched(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	d := (&Downloader{Cache: NewCache(t.TempDir())}).WithReadOnly()
+	got, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", "")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if string(got) != "payload" {
+		t.Fatalf("unexpected content: %q", string(got))
+	}
+
+	entries, err := d.Cache.List()
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected read-only fetch not to populate the cache, got %d entries", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(d.Cache.Dir, "partial")); !os.IsNotExist(err) {
+		t.Fatalf("expected no partial download directory, got %v", err)
+	}
+}
+
+func TestCacheSkipsMalformedEntriesAndTempBlobs(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	if _, err := cache.Store(Entry{URL: "https://example.com/tool", LastUsed: time.Now()}, []byte("tool")); err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+	malformed := []byte(`{"key":"abc","url":"https://example.com/bad","sha256":"short"}`)
+	if err := os.WriteFile(filepath.Join(cache.entriesDir(), "abc.json"), malformed, 0o644); err != nil {
+		t.Fatalf("failed to write malformed entry: %v", err)
+	}
+	// A blob still being written by a concurrent Fetch
+	inFlight := filepath.Join(cache.blobsDir(), ".tmp-123")
+	if err := os.WriteFile(inFlight, []byte("partial"), 0o644); err != nil {
+		t.Fatalf("failed to write temporary blob: %v", err)
+	}
+
+	entries, err := cache.List()
+	if err != nil || len(entries) != 1 || entries[0].URL != "https://example.com/tool" {
+		t.Fatalf("expected only the valid entry, got %+v (%v)", entries, err)
+	}
+	if _, err := cache.Prune(time.Hour); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if _, err := os.Stat(inFlight); err != nil {
+		t.Fatalf("expected Prune to keep the temporary blob: %v", err)
+	}
+}
+
+func TestFetchRecoversFromCorruptCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	url := server.URL + "/tool"
+	checksum := "sha256:" + sha256Hex([]byte("payload"))
+	if _, err := d.Fetch(context.Background(), url, checksum); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+
+	// A damaged blob is dropped and downloaded again
+	entry, err := d.Cache.Lookup(url, checksum)
+	if err != nil || entry == nil {
+		t.Fatalf("expected a cached entry, got %v (%v)", entry, err)
+	}
+	if err := os.WriteFile(d.Cache.blobPath(entry.SHA256), []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("failed to corrupt blob: %v", err)
+	}
+	if got, err := d.Fetch(context.Background(), url, checksum); err != nil || string(got) != "payload" {
+		t.Fatalf("expected a fresh download after a corrupt blob, got %q (%v)", string(got), err)
+	}
+
+	// A truncated entry is removed and treated as a miss
+	key := CacheKey(url, checksum)
+	if err := os.WriteFile(d.Cache.entryPath(key), []byte(`{"key":`), 0o644); err != nil {
+		t.Fatalf("failed to corrupt entry: %v", err)
+	}
+	if got, err := d.Fetch(context.Background(), url, checksum); err != nil || string(got) != "payload" {
+		t.Fatalf("expected a fresh download after a corrupt entry, got %q (%v)", string(got), err)
+	}
+	if requests.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestCacheLookupRejectsEntriesOutsideBlobs(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	url := "https://example.com/tool"
+	key := CacheKey(url, "")
+	crafted := []byte(`{"key":"` + key + `","url":"` + url + `","sha256":"../../../etc/passwd"}`)
+	if err := os.MkdirAll(cache.entriesDir(), 0o755); err != nil {
+		t.Fatalf("failed to create entries directory: %v", err)
+	}
+	if err := os.WriteFile(cache.entryPath(key), crafted, 0o644); err != nil {
+		t.Fatalf("failed to write crafted entry: %v", err)
+	}
+
+	entry, err := cache.Lookup(url, "")
+	if err != nil || entry != nil {
+		t.Fatalf("expected a miss for a crafted entry, got %+v (%v)", entry, err)
+	}
+	if _, err := os.Stat(cache.entryPath(key)); !os.IsNotExist(err) {
+		t.Fatalf("expected the crafted entry to be removed")
+	}
+}
+
+func TestCacheListSkipsUnparseableEntries(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	if _, err := cache.Store(Entry{URL: "https://example.com/tool", LastUsed: time.Now()}, []byte("tool")); err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cache.entriesDir(), "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("failed to write broken entry: %v", err)
+	}
+
+	entries, err := cache.List()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected the valid entry only, got %+v (%v)", entries, err)
+	}
+	if _, err := cache.Prune(time.Hour); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+}
+
+func TestCachePruneAndClearPartialDownloads(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	if err := os.MkdirAll(cache.partialDir(), 0o700); err != nil {
+		t.Fatalf("failed to create partial directory: %v", err)
+	}
+	abandoned := CacheKey("https://example.com/abandoned", "")
+	active := CacheKey("https://example.com/active", "")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{abandoned + ".partial", abandoned + ".partial.json", abandoned + ".partial.lock", active + ".partial.lock", active + ".partial"} {
+		path := filepath.Join(cache.partialDir(), name)
+		if err := os.WriteFile(path, []byte("12345"), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if name != active+".partial" {
+			os.Chtimes(path, old, old)
+		}
+	}
+
+	freed, err := cache.Prune(24 * time.Hour)
+	if err != nil || freed != 15 {
+		t.Fatalf("expected the abandoned download to be freed, got %d (%v)", freed, err)
+	}
+	remaining, _ := os.ReadDir(cache.partialDir())
+	if len(remaining) != 2 {
+		t.Fatalf("expected the active download to be kept, got %d files", len(remaining))
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if _, err := os.Stat(cache.partialDir()); !os.IsNotExist(err) {
+		t.Fatalf("expected Clear to remove partial downloads")
+	}
+}
@@ -0,0 +1,156 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LOCK_POLL_INTERVAL is how often a transfer waiting for the lock of a
+// partial file held by another process retries
+const LOCK_POLL_INTERVAL = 100 * time.Millisecond
+
+// validator identifies the version of a file a partial download belongs to
+type validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ifRange returns the If-Range value that makes the server send the rest of
+// the same version only, or "" if a resume cannot be validated. Weak ETags
+// are not allowed in If-Range.
+func (v validator) ifRange() string {
+	if v.ETag != "" && !strings.HasPrefix(v.ETag, "W/") {
+		return v.ETag
+	}
+	return v.LastModified
+}
+
+// stagingDir returns a directory only the current user can write to for
+// in-progress downloads. With a cache it is <cache>/partial and kept, so a
+// later run can resume; without one it is a new temporary directory that the
+// returned cleanup removes.
+func (d *Downloader) stagingDir() (string, func(), error) {
+	if d.Cache == nil {
+		dir, err := os.MkdirTemp("", "nanolayer-download-*")
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to create download directory: %w", err)
+		}
+		return dir, func() { os.RemoveAll(dir) }, nil
+	}
+
+	dir := d.partialDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	if err := checkPrivate(dir); err != nil {
+		return "", nil, err
+	}
+	return dir, func() {}, nil
+}
+
+// checkPrivate ensures dir is a directory owned by the current user that
+// nobody else can write to, so no one can plant or redirect partial files
+func checkPrivate(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check download directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("download directory %s is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("download directory %s is owned by uid %d, not the current user", dir, stat.Uid)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to restrict download directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// openPartial opens a staged file without following symlinks
+func openPartial(path string, flags int) (*os.File, error) {
+	return os.OpenFile(path, flags|syscall.O_NOFOLLOW, 0600)
+}
+
+// lockPartial takes an exclusive lock beside partial, waiting while another
+// process or transfer holds it, so concurrent downloads of the same key never
+// write to the same partial file. The returned function releases the lock.
+func lockPartial(ctx context.Context, partial string) (func(), error) {
+	file, err := openPartial(partial+".lock", os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download lock: %w", err)
+	}
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock download: %w", err)
+		}
+		if err := sleep(ctx, LOCK_POLL_INTERVAL); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+func validatorPath(partial string) string {
+	return partial + ".json"
+}
+
+// readValidator returns the validator stored with partial, empty if there is
+// none or it cannot be read
+func readValidator(partial string) validator {
+	var v validator
+	file, err := openPartial(validatorPath(partial), os.O_RDONLY)
+	if err != nil {
+		return v
+	}
+	defer file.Close()
+	if json.NewDecoder(file).Decode(&v) != nil {
+		return validator{}
+	}
+	return v
+}
+
+// writeValidator stores v next to partial for later resumes
+func writeValidator(partial string, v validator) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	file, err := openPartial(validatorPath(partial), os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// removePartial removes a staged file and its validator
+func removePartial(partial string) {
+	os.Remove(partial)
+	os.Remove(validatorPath(partial))
+}
+
+// partialDir is where a cache keeps in-progress downloads between runs
+func (d *Downloader) partialDir() string {
+	return d.Cache.partialDir()
+}
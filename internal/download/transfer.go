@@ -0,0 +1,529 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/progress"
+)
+
+// transferResult is the outcome of a network transfer
+type transferResult struct {
+	content      []byte
+	etag         string
+	lastModified string
+	// notModified is set when the server confirmed the cached copy is current
+	notModified bool
+}
+
+// errRestart signals that the partial file is unusable and the transfer has
+// to start over from the first byte
+var errRestart = errors.New("partial download cannot be resumed")
+
+// permanentError marks failures that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// retryableError marks failures the server expects to go away, with the
+// delay it asked for in Retry-After
+type retryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// MAX_RETRY_AFTER caps the delay honoured from a Retry-After header
+const MAX_RETRY_AFTER = 5 * time.Minute
+
+// statusError classifies an unexpected response: server errors and 429 Too
+// Many Requests are retried, any other status is permanent
+func statusError(resp *http.Response) error {
+	err := httpStatusError(resp.StatusCode)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return &retryableError{err: err, after: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+	return &permanentError{err: err}
+}
+
+// retryAfter parses a Retry-After value given in seconds or as an HTTP date
+func retryAfter(value string) time.Duration {
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		delay = time.Until(at)
+	}
+	return min(max(delay, 0), MAX_RETRY_AFTER)
+}
+
+// retryDelay returns the pause before the given retry: the doubled
+// RetryDelay, or longer when the last response asked for it
+func (d *Downloader) retryDelay(attempt int, lastErr error) time.Duration {
+	delay := d.RetryDelay << (attempt - 1)
+	var retryable *retryableError
+	if errors.As(lastErr, &retryable) {
+		delay = max(delay, retryable.after)
+	}
+	return delay
+}
+
+// httpStatusError describes an unexpected response status, wrapping
+// ErrNotFound when the server has no file at the URL
+func httpStatusError(status int) error {
+	err := fmt.Errorf("asset URL returned status %d", status)
+	if status == http.StatusNotFound || status == http.StatusGone {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+// transfer downloads url into a .partial file and returns its content.
+//
+// When the server advertises byte ranges and d.Chunks > 1 the file is fetched
+// as parallel chunks, otherwise as a single stream that is resumed with a
+// Range request after an interruption, including one from an earlier run
+// when a cache is configured. Partial files are only written to a private
+// directory, locked for the whole transfer and only resumed with a validator
+// of the version they belong to.
+func (d *Downloader) transfer(ctx context.Context, url string, key string, cached *Entry) (*transferResult, error) {
+	dir, cleanup, err := d.stagingDir()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	partial := filepath.Join(dir, key+".partial")
+	unlock, err := lockPartial(ctx, partial)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var result *transferResult
+	if d.Chunks > 1 && cached == nil {
+		result, err = d.transferChunks(ctx, url, partial)
+	}
+	if result == nil && err == nil {
+		result, err = d.transferStream(ctx, url, partial, cached)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.notModified {
+		return result, nil
+	}
+
+	content, err := os.ReadFile(partial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downloaded file: %w", err)
+	}
+	removePartial(partial)
+	result.content = content
+	return result, nil
+}
+
+// transferInMemory downloads url into memory without resuming, for
+// read-only downloaders. Only responses asking for a retry are retried.
+func (d *Downloader) transferInMemory(ctx context.Context, url string, cached *Entry) (*transferResult, error) {
+	var lastErr error
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, d.retryDelay(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := d.fetchInMemory(ctx, url, cached)
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return result, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to download asset from URL: %w", lastErr)
+}
+
+// fetchInMemory performs a single GET for transferInMemory
+func (d *Downloader) fetchInMemory(ctx context.Context, url string, cached *Entry) (*transferResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download asset from URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result := &transferResult{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		result.notModified = true
+		return result, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return nil, statusError(resp)
+	case resp.StatusCode != http.StatusOK:
+		return nil, httpStatusError(resp.StatusCode)
+	}
+
+	reporter := d.reporter()
+	reporter.Start(path.Base(req.URL.Path), resp.ContentLength)
+	content, err := io.ReadAll(&progress.Reader{Reader: resp.Body, Reporter: reporter})
+	reporter.Finish(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download asset from URL: %w", err)
+	}
+	result.content = content
+	return result, nil
+}
+
+// transferStream downloads url as a single stream, resuming on failures
+func (d *Downloader) transferStream(ctx context.Context, url string, partial string, cached *Entry) (*transferResult, error) {
+	var lastErr error
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, d.retryDelay(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := d.streamOnce(ctx, url, partial, cached)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			// Keep the partial file so the next run resumes it
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, errRestart) {
+			removePartial(partial)
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return nil, permanent.err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to download asset from URL: %w", lastErr)
+}
+
+// streamOnce performs a single GET, appending to the partial file when it
+// already holds the beginning of the content
+func (d *Downloader) streamOnce(ctx context.Context, url string, partial string, cached *Entry) (*transferResult, error) {
+	var offset int64
+	if info, err := os.Lstat(partial); err == nil && info.Mode().IsRegular() {
+		offset = info.Size()
+	}
+	resume := readValidator(partial).ifRange()
+	if offset > 0 && resume == "" {
+		// Without a validator the partial file may belong to another
+		// version of the file, start over
+		removePartial(partial)
+		offset = 0
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &permanentError{err: fmt.Errorf("failed to create request: %w", err)}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", resume)
+	} else if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &transferResult{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	var total int64 = -1
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached != nil && offset == 0 {
+			result.notModified = true
+			return result, nil
+		}
+		return nil, statusError(resp)
+	case http.StatusOK:
+		// The server ignored the range, start over
+		offset = 0
+		flags |= os.O_TRUNC
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return result, errRestart
+		}
+		flags |= os.O_APPEND
+		total = size
+	case http.StatusRequestedRangeNotSatisfiable:
+		return result, errRestart
+	default:
+		return nil, statusError(resp)
+	}
+
+	if err := writeValidator(partial, validator{ETag: result.etag, LastModified: result.lastModified}); err != nil {
+		return nil, &permanentError{err: fmt.Errorf("failed to create download file: %w", err)}
+	}
+	file, err := openPartial(partial, flags)
+	if err != nil {
+		return nil, &permanentError{err: fmt.Errorf("failed to create download file: %w", err)}
+	}
+	defer file.Close()
+
+	reporter := d.reporter()
+	reporter.Start(path.Base(req.URL.Path), total)
+	written, err := io.Copy(file, &progress.Reader{Reader: resp.Body, Reporter: reporter, Offset: offset})
+	reporter.Finish(err)
+	if err != nil {
+		return result, err
+	}
+
+	if total >= 0 && offset+written != total {
+		return result, fmt.Errorf("incomplete download: got %d of %d bytes", offset+written, total)
+	}
+	return result, nil
+}
+
+// transferChunks downloads url with parallel range requests. It returns a nil
+// result without error when the server does not support ranges, has no strong
+// validator to keep the chunks to one version, ignores a range, or the file
+// is too small to be worth splitting.
+func (d *Downloader) transferChunks(ctx context.Context, url string, partial string) (*transferResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, nil
+	}
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return nil, ctx.Err()
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Accept-Ranges") != "bytes" {
+		return nil, nil
+	}
+	ifRange := validator{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}.ifRange()
+	if ifRange == "" {
+		return nil, nil
+	}
+	size := resp.ContentLength
+	minChunk := d.MinChunkSize
+	if minChunk < 1 {
+		minChunk = 1
+	}
+	if size < 2*minChunk {
+		return nil, nil
+	}
+
+	chunks := int64(d.Chunks)
+	if size/chunks < minChunk {
+		chunks = size / minChunk
+	}
+	chunkSize := (size + chunks - 1) / chunks
+
+	file, err := openPartial(partial, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download file: %w", err)
+	}
+	defer file.Close()
+	if err := file.Truncate(size); err != nil {
+		return nil, fmt.Errorf("failed to allocate download file: %w", err)
+	}
+
+	reporter := d.reporter()
+	reporter.Start(path.Base(resp.Request.URL.Path), size)
+	var transferred atomic.Int64
+	var wg sync.WaitGroup
+	errs := make([]error, chunks)
+	for i := int64(0); i < chunks; i++ {
+		start := i * chunkSize
+		end := min(start+chunkSize, size) - 1
+		wg.Add(1)
+		go func(index int64) {
+			defer wg.Done()
+			errs[index] = d.fetchChunk(ctx, url, file, start, end, ifRange, &transferred, reporter)
+		}(i)
+	}
+	wg.Wait()
+
+	err = errors.Join(errs...)
+	reporter.Finish(err)
+	if err != nil {
+		// The preallocated file has gaps and must not be resumed as a stream
+		removePartial(partial)
+		if errors.Is(err, errRestart) && ctx.Err() == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to download asset from URL: %w", err)
+	}
+
+	return &transferResult{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// fetchChunk writes the byte range [start, end] of url into file, resuming
+// the range after interruptions. The range is only served for the version
+// matching ifRange; errRestart reports a full response instead.
+func (d *Downloader) fetchChunk(ctx context.Context, url string, file *os.File, start int64, end int64, ifRange string, transferred *atomic.Int64, reporter progress.Reporter) error {
+	offset := start
+	var lastErr error
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, d.retryDelay(attempt, lastErr)); err != nil {
+				return err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end))
+		req.Header.Set("If-Range", ifRange)
+
+		resp, err := d.client().Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			// The file changed or the server ignored the range
+			resp.Body.Close()
+			return errRestart
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			resp.Body.Close()
+			err := statusError(resp)
+			var retryable *retryableError
+			if !errors.As(err, &retryable) {
+				return err
+			}
+			lastErr = err
+			continue
+		}
+
+		buf := make([]byte, 32*1024)
+		for offset <= end {
+			n, readErr := resp.Body.Read(buf)
+			if n > 0 {
+				n = int(min(int64(n), end-offset+1))
+				if _, err := file.WriteAt(buf[:n], offset); err != nil {
+					resp.Body.Close()
+					return fmt.Errorf("failed to write download file: %w", err)
+				}
+				offset += int64(n)
+				reporter.Update(transferred.Add(int64(n)))
+			}
+			if readErr != nil {
+				if readErr != io.EOF {
+					lastErr = readErr
+				}
+				break
+			}
+		}
+		resp.Body.Close()
+
+		if offset > end {
+			return nil
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("incomplete chunk: got %d of %d bytes", offset-start, end-start+1)
+		}
+	}
+	return lastErr
+}
+
+// sleep waits for delay or until ctx is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseContentRange parses a "bytes start-end/size" header
+func parseContentRange(value string) (int64, int64, bool) {
+	value, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	span, sizeValue, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	startValue, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startValue, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	size := int64(-1)
+	if sizeValue != "*" {
+		size, err = strconv.ParseInt(sizeValue, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, size, true
+}
@@ -0,0 +1,424 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchResumesInterruptedTransfer(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var requests atomic.Int32
+	var rangeHeader, ifRangeHeader atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if requests.Add(1) == 1 {
+			// Send half of the body, then drop the connection
+			w.Header().Set("Content-Length", "10000")
+			w.Write(content[:5000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		rangeHeader.Store(r.Header.Get("Range"))
+		ifRangeHeader.Store(r.Header.Get("If-Range"))
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir()), Retries: 2}
+	got, err := d.Fetch(context.Background(), server.URL+"/tool.tar.gz", "sha256:"+sha256Hex(content))
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("resumed content does not match")
+	}
+	if rangeHeader.Load() != "bytes=5000-" || ifRangeHeader.Load() != `"v1"` {
+		t.Fatalf("expected resume from byte 5000 of version \"v1\", got Range %q If-Range %q", rangeHeader.Load(), ifRangeHeader.Load())
+	}
+
+	if _, err := os.Stat(d.partialPath(CacheKey(server.URL+"/tool.tar.gz", "sha256:"+sha256Hex(content)))); !os.IsNotExist(err) {
+		t.Fatalf("expected partial file to be removed after completion")
+	}
+}
+
+func TestFetchResumesPartialFromEarlierRun(t *testing.T) {
+	content := []byte("hello resumable world")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "bytes=6-" || r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("unexpected Range %q and If-Range %q", r.Header.Get("Range"), r.Header.Get("If-Range"))
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	url := server.URL + "/tool"
+	partial := d.partialPath(CacheKey(url, ""))
+	if err := os.MkdirAll(filepath.Dir(partial), 0o755); err != nil {
+		t.Fatalf("failed to create partial directory: %v", err)
+	}
+	if err := os.WriteFile(partial, content[:6], 0o644); err != nil {
+		t.Fatalf("failed to write partial file: %v", err)
+	}
+	if err := writeValidator(partial, validator{ETag: `"v1"`}); err != nil {
+		t.Fatalf("failed to write validator: %v", err)
+	}
+
+	got, err := d.Fetch(context.Background(), url, "")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("unexpected content: %q", string(got))
+	}
+}
+
+func TestFetchDiscardsPartialWithoutValidator(t *testing.T) {
+	content := []byte("new upstream content")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			t.Errorf("expected no resume without a validator, got Range %q", r.Header.Get("Range"))
+		}
+		http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	url := server.URL + "/tool"
+	partial := d.partialPath(CacheKey(url, ""))
+	if err := os.MkdirAll(filepath.Dir(partial), 0o700); err != nil {
+		t.Fatalf("failed to create partial directory: %v", err)
+	}
+	if err := os.WriteFile(partial, []byte("planted"), 0o644); err != nil {
+		t.Fatalf("failed to write partial file: %v", err)
+	}
+
+	got, err := d.Fetch(context.Background(), url, "")
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("unexpected content %q (%v)", string(got), err)
+	}
+}
+
+func TestFetchRefusesSymlinkedPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	url := server.URL + "/tool"
+	partial := d.partialPath(CacheKey(url, ""))
+	if err := os.MkdirAll(filepath.Dir(partial), 0o700); err != nil {
+		t.Fatalf("failed to create partial directory: %v", err)
+	}
+	victim := filepath.Join(t.TempDir(), "victim")
+	if err := os.WriteFile(victim, []byte("keep"), 0o644); err != nil {
+		t.Fatalf("failed to write victim file: %v", err)
+	}
+	if err := os.Symlink(victim, partial); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if _, err := d.Fetch(context.Background(), url, ""); err == nil {
+		t.Fatalf("expected an error for a symlinked partial file")
+	}
+	if content, _ := os.ReadFile(victim); string(content) != "keep" {
+		t.Fatalf("symlink target was modified: %q", string(content))
+	}
+}
+
+func TestStagingDirIsPrivate(t *testing.T) {
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	if err := os.MkdirAll(d.partialDir(), 0o777); err != nil {
+		t.Fatalf("failed to create partial directory: %v", err)
+	}
+	os.Chmod(d.partialDir(), 0o777)
+	dir, cleanup, err := d.stagingDir()
+	if err != nil {
+		t.Fatalf("stagingDir returned error: %v", err)
+	}
+	defer cleanup()
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+		t.Fatalf("expected a 0700 staging directory, got %v (%v)", info.Mode().Perm(), err)
+	}
+
+	temporary, cleanup, err := (&Downloader{}).stagingDir()
+	if err != nil {
+		t.Fatalf("stagingDir returned error: %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(temporary); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary staging directory to be removed")
+	}
+}
+
+func TestFetchRestartsWhenRangeIgnored(t *testing.T) {
+	content := []byte("complete body")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir())}
+	url := server.URL + "/tool"
+	partial := d.partialPath(CacheKey(url, ""))
+	if err := os.MkdirAll(filepath.Dir(partial), 0o755); err != nil {
+		t.Fatalf("failed to create partial directory: %v", err)
+	}
+	if err := os.WriteFile(partial, []byte("stale-bytes"), 0o644); err != nil {
+		t.Fatalf("failed to write partial file: %v", err)
+	}
+
+	got, err := d.Fetch(context.Background(), url, "")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("unexpected content: %q", string(got))
+	}
+}
+
+func TestFetchParallelChunks(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 100)
+	var rangeRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.Header.Get("Range") != "" {
+			rangeRequests.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Chunks: 4, MinChunkSize: 100}
+	got, err := d.Fetch(context.Background(), server.URL+"/tool", "sha256:"+sha256Hex(content))
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("chunked content does not match")
+	}
+	if rangeRequests.Load() != 4 {
+		t.Fatalf("expected 4 range requests, got %d", rangeRequests.Load())
+	}
+}
+
+func TestFetchChunksRequireStrongValidator(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 100)
+	for _, etag := range []string{"", `W/"v1"`} {
+		var rangeRequests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet && r.Header.Get("Range") != "" {
+				rangeRequests.Add(1)
+			}
+			if etag != "" {
+				w.Header().Set("ETag", etag)
+			}
+			http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+		}))
+
+		d := &Downloader{Chunks: 4, MinChunkSize: 100}
+		got, err := d.Fetch(context.Background(), server.URL+"/tool", "")
+		server.Close()
+		if err != nil || !bytes.Equal(got, content) {
+			t.Fatalf("ETag %q: unexpected content (%v)", etag, err)
+		}
+		if rangeRequests.Load() != 0 {
+			t.Fatalf("ETag %q: expected a single stream, got %d range requests", etag, rangeRequests.Load())
+		}
+	}
+}
+
+func TestFetchChunksFallBackToStreamWhenFileChanges(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 100)
+	var heads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+			w.Header().Set("ETag", `"old"`)
+		} else {
+			// A new version was published after the HEAD request
+			w.Header().Set("ETag", `"new"`)
+		}
+		http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Chunks: 4, MinChunkSize: 100}
+	got, err := d.Fetch(context.Background(), server.URL+"/tool", "")
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("expected the stream fallback to download the file, got %v", err)
+	}
+	if heads.Load() != 1 {
+		t.Fatalf("expected a single HEAD request, got %d", heads.Load())
+	}
+}
+
+func TestFetchDoesNotRetryPermanentErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	d := &Downloader{Retries: 3}
+	_, err := d.Fetch(context.Background(), server.URL+"/missing", "")
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "status 404") {
+		t.Fatalf("expected 404 error, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("expected a single request, got %d", requests.Load())
+	}
+}
+
+func TestFetchRetriesServerErrorsAndRateLimits(t *testing.T) {
+	for _, readOnly := range []bool{false, true} {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch requests.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case 2:
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+			default:
+				w.Write([]byte("payload"))
+			}
+		}))
+
+		d := &Downloader{Retries: 3, RetryDelay: time.Millisecond, ReadOnly: readOnly}
+		started := time.Now()
+		got, err := d.Fetch(context.Background(), server.URL+"/tool", "")
+		server.Close()
+		if err != nil || string(got) != "payload" {
+			t.Fatalf("read-only %v: unexpected content %q (%v)", readOnly, string(got), err)
+		}
+		if requests.Load() != 3 {
+			t.Fatalf("read-only %v: expected 3 requests, got %d", readOnly, requests.Load())
+		}
+		if elapsed := time.Since(started); elapsed < time.Second {
+			t.Fatalf("read-only %v: expected Retry-After to be honoured, retried after %v", readOnly, elapsed)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if got := retryAfter("3"); got != 3*time.Second {
+		t.Fatalf("expected 3s, got %v", got)
+	}
+	if got := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); got != MAX_RETRY_AFTER {
+		t.Fatalf("expected the delay to be capped, got %v", got)
+	}
+	if got := retryAfter("soon"); got != 0 {
+		t.Fatalf("expected no delay for an invalid value, got %v", got)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, size, ok := parseContentRange("bytes 100-199/1000")
+	if !ok || start != 100 || size != 1000 {
+		t.Fatalf("unexpected result: start=%d size=%d ok=%v", start, size, ok)
+	}
+
+	start, size, ok = parseContentRange("bytes 5-9/*")
+	if !ok || start != 5 || size != -1 {
+		t.Fatalf("unexpected result for unknown size: start=%d size=%d ok=%v", start, size, ok)
+	}
+
+	if _, _, ok := parseContentRange("items 0-1/2"); ok {
+		t.Fatalf("expected invalid unit to be rejected")
+	}
+}
+
+func TestFetchCanceledKeepsPartialFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10000")
+		w.Write(bytes.Repeat([]byte("x"), 5000))
+		w.(http.Flusher).Flush()
+		// Stall until the client gives up
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	d := &Downloader{Cache: NewCache(t.TempDir()), Retries: 3, RetryDelay: time.Hour}
+	if _, err := d.Fetch(ctx, server.URL+"/tool.tar.gz", ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if info, err := os.Stat(d.partialPath(CacheKey(server.URL+"/tool.tar.gz", ""))); err != nil || info.Size() != 5000 {
+		t.Fatalf("expected the partial file to be kept for resuming, got %v", err)
+	}
+}
+
+func TestFetchSerializesConcurrentTransfersOfOneKey(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var active, maxActive atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := active.Add(1)
+		defer active.Add(-1)
+		if current > maxActive.Load() {
+			maxActive.Store(current)
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	errs := make(chan error, 4)
+	for range 4 {
+		go func() {
+			// Separate downloaders share the cache like separate processes
+			d := &Downloader{Cache: NewCache(dir)}
+			got, err := d.Fetch(context.Background(), server.URL+"/tool", "")
+			if err == nil && !bytes.Equal(got, content) {
+				err = errors.New("content mixed from concurrent transfers")
+			}
+			errs <- err
+		}()
+	}
+	for range 4 {
+		if err := <-errs; err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+	}
+	if maxActive.Load() != 1 {
+		t.Fatalf("expected one transfer at a time, got %d", maxActive.Load())
+	}
+}
+
+func TestLockPartialWaitsForHolder(t *testing.T) {
+	partial := filepath.Join(t.TempDir(), "key.partial")
+	unlock, err := lockPartial(context.Background(), partial)
+	if err != nil {
+		t.Fatalf("lockPartial returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*LOCK_POLL_INTERVAL)
+	defer cancel()
+	if _, err := lockPartial(ctx, partial); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to wait for the held lock, got %v", err)
+	}
+
+	unlock()
+	unlockAgain, err := lockPartial(context.Background(), partial)
+	if err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+	unlockAgain()
+}
@@ -0,0 +1,142 @@
+package elfinspect
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// ErrNotELF is returned by Inspect for content that is not an ELF file
+var ErrNotELF = errors.New("not an ELF file")
+
+// ErrArchitectureMismatch is returned by Check for a binary built for another
+// architecture
+var ErrArchitectureMismatch = errors.New("architecture mismatch")
+
+// ErrMissingInterpreter is returned by Check for a binary whose dynamic loader
+// is not installed, typically a glibc binary on a musl system or vice versa
+var ErrMissingInterpreter = errors.New("missing ELF interpreter")
+
+// Info describes an ELF file
+type Info struct {
+	Type      elf.Type
+	Machine   elf.Machine
+	Class     elf.Class
+	ByteOrder binary.ByteOrder
+	// Interpreter is the dynamic loader (PT_INTERP), empty for static binaries
+	Interpreter string
+}
+
+// IsELF reports whether content starts with the ELF magic number
+func IsELF(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(elf.ELFMAG))
+}
+
+// Inspect parses the ELF header and program interpreter of content
+func Inspect(content []byte) (*Info, error) {
+	if !IsELF(content) {
+		return nil, ErrNotELF
+	}
+	file, err := elf.NewFile(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ELF file: %w", err)
+	}
+	defer file.Close()
+
+	info := &Info{Type: file.Type, Machine: file.Machine, Class: file.Class, ByteOrder: file.ByteOrder}
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		data := make([]byte, prog.Filesz)
+		if _, err := prog.ReadAt(data, 0); err != nil {
+			return nil, fmt.Errorf("failed to read ELF interpreter: %w", err)
+		}
+		info.Interpreter = strings.TrimRight(string(data), "\x00")
+	}
+	return info, nil
+}
+
+// Static reports whether the binary runs without a dynamic loader
+func (i *Info) Static() bool {
+	return i.Interpreter == ""
+}
+
+// Libc returns the libc the binary is linked against, judged by its loader
+func (i *Info) Libc() linuxsystem.Libc {
+	name := filepath.Base(i.Interpreter)
+	switch {
+	case i.Static():
+		return linuxsystem.UnknownLibc
+	case strings.HasPrefix(name, "ld-musl"):
+		return linuxsystem.Musl
+	case strings.HasPrefix(name, "ld-linux"), strings.HasPrefix(name, "ld64.so"), strings.HasPrefix(name, "ld.so"):
+		return linuxsystem.Glibc
+	}
+	return linuxsystem.UnknownLibc
+}
+
+// Matches reports whether the binary runs on arch. OTHER matches any binary.
+func (i *Info) Matches(arch linuxsystem.Architecture) bool {
+	is64 := i.Class == elf.ELFCLASS64
+	little := i.ByteOrder == binary.LittleEndian
+	switch arch {
+	case linuxsystem.X86_64:
+		return i.Machine == elf.EM_X86_64
+	case linuxsystem.ARM64:
+		return i.Machine == elf.EM_AARCH64
+	case linuxsystem.ARMV5, linuxsystem.ARMV6, linuxsystem.ARMV7, linuxsystem.ARMHF, linuxsystem.ARM32:
+		return i.Machine == elf.EM_ARM
+	case linuxsystem.I386, linuxsystem.I686:
+		return i.Machine == elf.EM_386
+	case linuxsystem.PPC64:
+		return i.Machine == elf.EM_PPC64 && !little
+	case linuxsystem.PPC64LE:
+		return i.Machine == elf.EM_PPC64 && little
+	case linuxsystem.S390:
+		return i.Machine == elf.EM_S390
+	case linuxsystem.RISCV64:
+		return i.Machine == elf.EM_RISCV && is64
+	case linuxsystem.LOONGARCH64:
+		return i.Machine == elf.EM_LOONGARCH
+	case linuxsystem.MIPS64:
+		return i.Machine == elf.EM_MIPS && is64 && !little
+	case linuxsystem.MIPS64LE:
+		return i.Machine == elf.EM_MIPS && is64 && little
+	}
+	return true
+}
+
+// Check verifies that content, if it is an ELF binary, is built for arch and
+// that its dynamic loader exists below root. Scripts and other files pass.
+// An empty root skips the loader check, e.g. when installing for another
+// architecture.
+func Check(content []byte, arch linuxsystem.Architecture, root string) error {
+	info, err := Inspect(content)
+	if errors.Is(err, ErrNotELF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Matches(arch) {
+		return fmt.Errorf("%w: binary is built for %s, expected %s", ErrArchitectureMismatch, info.Machine, arch)
+	}
+	if info.Static() || root == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(root, info.Interpreter)); err != nil {
+		if libc := info.Libc(); libc != linuxsystem.UnknownLibc {
+			return fmt.Errorf("%w: %s not found, the binary needs %s", ErrMissingInterpreter, info.Interpreter, libc)
+		}
+		return fmt.Errorf("%w: %s not found", ErrMissingInterpreter, info.Interpreter)
+	}
+	return nil
+}
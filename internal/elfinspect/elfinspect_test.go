@@ -0,0 +1,113 @@
+package elfinspect
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// elf64 builds a minimal little endian 64-bit ELF executable for machine,
+// with a PT_INTERP program header if interpreter is not empty
+func elf64(machine elf.Machine, interpreter string) []byte {
+	header := make([]byte, 64)
+	copy(header, elf.ELFMAG)
+	header[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	order := binary.LittleEndian
+	order.PutUint16(header[16:], uint16(elf.ET_EXEC))
+	order.PutUint16(header[18:], uint16(machine))
+	order.PutUint32(header[20:], uint32(elf.EV_CURRENT))
+	order.PutUint16(header[52:], 64)
+	if interpreter == "" {
+		return header
+	}
+
+	order.PutUint64(header[32:], 64) // e_phoff
+	order.PutUint16(header[54:], 56) // e_phentsize
+	order.PutUint16(header[56:], 1)  // e_phnum
+	prog := make([]byte, 56)
+	data := append([]byte(interpreter), 0)
+	order.PutUint32(prog[0:], uint32(elf.PT_INTERP))
+	order.PutUint64(prog[8:], 120)                // p_offset
+	order.PutUint64(prog[32:], uint64(len(data))) // p_filesz
+	order.PutUint64(prog[40:], uint64(len(data))) // p_memsz
+	return append(append(header, prog...), data...)
+}
+
+func TestInspect(t *testing.T) {
+	info, err := Inspect(elf64(elf.EM_AARCH64, "/lib/ld-musl-aarch64.so.1"))
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if info.Machine != elf.EM_AARCH64 || info.Interpreter != "/lib/ld-musl-aarch64.so.1" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if info.Libc() != linuxsystem.Musl || info.Static() {
+		t.Fatalf("expected a dynamic musl binary, got %+v", info)
+	}
+
+	if _, err := Inspect([]byte("#!/bin/sh\necho hi\n")); !errors.Is(err, ErrNotELF) {
+		t.Fatalf("expected ErrNotELF, got %v", err)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		machine elf.Machine
+		arch    linuxsystem.Architecture
+		want    bool
+	}{
+		{elf.EM_X86_64, linuxsystem.X86_64, true},
+		{elf.EM_X86_64, linuxsystem.ARM64, false},
+		{elf.EM_AARCH64, linuxsystem.ARM64, true},
+		{elf.EM_PPC64, linuxsystem.PPC64LE, true},
+		{elf.EM_PPC64, linuxsystem.PPC64, false},
+		{elf.EM_RISCV, linuxsystem.RISCV64, true},
+		{elf.EM_MIPS, linuxsystem.OTHER, true},
+	}
+	for _, tt := range tests {
+		info, err := Inspect(elf64(tt.machine, ""))
+		if err != nil {
+			t.Fatalf("Inspect failed: %v", err)
+		}
+		if got := info.Matches(tt.arch); got != tt.want {
+			t.Fatalf("%s.Matches(%s) = %v, want %v", tt.machine, tt.arch, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	root := t.TempDir()
+	loader := filepath.Join(root, "lib64", "ld-linux-x86-64.so.2")
+	if err := os.MkdirAll(filepath.Dir(loader), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(loader, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Check([]byte("#!/bin/sh\n"), linuxsystem.X86_64, root); err != nil {
+		t.Fatalf("scripts should pass, got %v", err)
+	}
+	if err := Check(elf64(elf.EM_X86_64, ""), linuxsystem.X86_64, root); err != nil {
+		t.Fatalf("static binaries should pass, got %v", err)
+	}
+	if err := Check(elf64(elf.EM_X86_64, "/lib64/ld-linux-x86-64.so.2"), linuxsystem.X86_64, root); err != nil {
+		t.Fatalf("expected the loader to be found, got %v", err)
+	}
+	if err := Check(elf64(elf.EM_AARCH64, ""), linuxsystem.X86_64, root); !errors.Is(err, ErrArchitectureMismatch) {
+		t.Fatalf("expected ErrArchitectureMismatch, got %v", err)
+	}
+	if err := Check(elf64(elf.EM_X86_64, "/lib/ld-musl-x86_64.so.1"), linuxsystem.X86_64, root); !errors.Is(err, ErrMissingInterpreter) {
+		t.Fatalf("expected ErrMissingInterpreter, got %v", err)
+	}
+	if err := Check(elf64(elf.EM_X86_64, "/lib/ld-musl-x86_64.so.1"), linuxsystem.X86_64, ""); err != nil {
+		t.Fatalf("an empty root should skip the loader check, got %v", err)
+	}
+}
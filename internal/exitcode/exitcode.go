@@ -0,0 +1,90 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+)
+
+// Exit codes of nanolayer, documented in the README
+const (
+	OK                  = 0
+	FAILURE             = 1
+	USAGE               = 2
+	UNSUPPORTED_DISTRO  = 3
+	NOT_FOUND           = 4
+	CHECKSUM_MISMATCH   = 5
+	NETWORK             = 6
+	RATE_LIMITED        = 7
+	VERIFICATION_FAILED = 8
+	PRIVILEGES_REQUIRED = 9
+	// TIMEOUT and INTERRUPTED follow timeout(1) and shells
+	TIMEOUT     = 124
+	INTERRUPTED = 130
+)
+
+// UsageError marks an invalid command line
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// Usagef returns a UsageError with a formatted message
+func Usagef(format string, args ...any) error {
+	return &UsageError{Err: fmt.Errorf(format, args...)}
+}
+
+// codes maps errors to exit codes, the first match wins
+var codes = []struct {
+	errs []error
+	code int
+}{
+	{[]error{context.DeadlineExceeded}, TIMEOUT},
+	{[]error{context.Canceled}, INTERRUPTED},
+	{[]error{github.ErrRateLimited}, RATE_LIMITED},
+	{[]error{download.ErrChecksumMismatch}, CHECKSUM_MISMATCH},
+	{[]error{github.ErrReleaseNotFound, github.ErrAssetNotFound, download.ErrNotFound}, NOT_FOUND},
+	{[]error{installers.ErrUnsupportedDistro}, UNSUPPORTED_DISTRO},
+	{[]error{installers.ErrPrivilegesRequired}, PRIVILEGES_REQUIRED},
+	{[]error{github.ErrVerificationFailed, elfinspect.ErrArchitectureMismatch, elfinspect.ErrMissingInterpreter}, VERIFICATION_FAILED},
+	{[]error{download.ErrNotCached}, NETWORK},
+}
+
+// For returns the exit code for err: OK if nil, FAILURE for errors without
+// a more specific code
+func For(err error) int {
+	if err == nil {
+		return OK
+	}
+	for _, entry := range codes {
+		for _, target := range entry.errs {
+			if errors.Is(err, target) {
+				return entry.code
+			}
+		}
+	}
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		return USAGE
+	}
+	// Transport failures such as DNS errors, refused connections and TLS
+	// problems surface as *url.Error, which is a net.Error
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return NETWORK
+	}
+	return FAILURE
+}
@@ -0,0 +1,43 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+)
+
+func TestFor(t *testing.T) {
+	network := &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("connection refused")}
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, OK},
+		{errors.New("boom"), FAILURE},
+		{Usagef("repository argument is required"), USAGE},
+		{fmt.Errorf("apk: %w", installers.ErrUnsupportedDistro), UNSUPPORTED_DISTRO},
+		{fmt.Errorf("failed to get asset URL: %w", github.ErrAssetNotFound), NOT_FOUND},
+		{fmt.Errorf("failed to resolve version: %w", github.ErrReleaseNotFound), NOT_FOUND},
+		{fmt.Errorf("%w: asset URL returned status 404", download.ErrNotFound), NOT_FOUND},
+		{fmt.Errorf("%w: expected sha256 00", download.ErrChecksumMismatch), CHECKSUM_MISMATCH},
+		{fmt.Errorf("failed to download asset from URL: %w", network), NETWORK},
+		{fmt.Errorf("offline mode: %w", download.ErrNotCached), NETWORK},
+		{fmt.Errorf("%w: %w", github.ErrRateLimited, errors.New("status 403")), RATE_LIMITED},
+		{fmt.Errorf("verify: %w", github.ErrVerificationFailed), VERIFICATION_FAILED},
+		{fmt.Errorf("write: %w", installers.ErrPrivilegesRequired), PRIVILEGES_REQUIRED},
+		{context.DeadlineExceeded, TIMEOUT},
+		// A canceled request is reported as interrupted, not as a network error
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: context.Canceled}, INTERRUPTED},
+	}
+	for _, test := range tests {
+		if got := For(test.err); got != test.want {
+			t.Fatalf("For(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal"
+)
+
+// CA_BUNDLE_ENV is the environment variable pointing at an extra CA bundle
+const CA_BUNDLE_ENV = "NANOLAYER_CA_BUNDLE"
+
+// Options configures the shared HTTP client.
+//
+// Proxies are always taken from HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+type Options struct {
+	// Timeout limits the duration of each request, no limit if zero
+	Timeout time.Duration
+	// CACertFile is a PEM bundle trusted in addition to the system roots
+	CACertFile string
+	// ClientCertFile and ClientKeyFile enable TLS client authentication
+	ClientCertFile string
+	ClientKeyFile  string
+	// InsecureSkipVerify disables TLS certificate verification
+	InsecureSkipVerify bool
+}
+
+// Rewrites are the mirror prefix rewrites applied to every request
+var Rewrites []Rewrite
+
+var (
+	timeout time.Duration
+	// base is the transport requests are sent through, http.DefaultTransport if nil
+	base http.RoundTripper
+)
+
+// Configure applies opts to every client subsequently returned by Client
+func Configure(opts Options) error {
+	if opts.CACertFile == "" {
+		opts.CACertFile = os.Getenv(CA_BUNDLE_ENV)
+	}
+
+	transport, err := newTransport(opts)
+	if err != nil {
+		return err
+	}
+	base = transport
+	timeout = opts.Timeout
+	return nil
+}
+
+// newTransport builds a transport for opts, or returns nil when the defaults suffice
+func newTransport(opts Options) (http.RoundTripper, error) {
+	if opts.CACertFile == "" && opts.ClientCertFile == "" && opts.ClientKeyFile == "" && !opts.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CACertFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		if opts.ClientCertFile == "" || opts.ClientKeyFile == "" {
+			return nil, fmt.Errorf("both a client certificate and a client key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	var transport *http.Transport
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+	} else {
+		transport = &http.Transport{}
+	}
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// UserAgent returns the User-Agent header sent with every request
+func UserAgent() string {
+	return fmt.Sprintf("nanolayer/%s (+https://github.com/devcontainer-community/nanolayer-go)", internal.Version)
+}
+
+// Client returns the HTTP client shared by all installers
+func Client() *http.Client {
+	return &http.Client{Transport: &transport{}, Timeout: timeout}
+}
+
+// transport applies mirror rewrites and common headers before delegating to
+// the configured base transport
+type transport struct{}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent())
+	}
+
+	if len(Rewrites) > 0 {
+		original := req.URL.String()
+		if rewritten := RewriteURL(Rewrites, original); rewritten != original {
+			target, err := url.Parse(rewritten)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mirror URL %q: %w", rewritten, err)
+			}
+			// Never leak credentials meant for the original host to a mirror
+			if target.Host != req.URL.Host {
+				req.Header.Del("Authorization")
+			}
+			req.URL = target
+			req.Host = target.Host
+		}
+	}
+
+	if base != nil {
+		return base.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClientSetsUserAgent(t *testing.T) {
+	var gotAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAgent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	resp, err := Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("request returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.HasPrefix(gotAgent, "nanolayer/") {
+		t.Fatalf("expected nanolayer User-Agent, got %q", gotAgent)
+	}
+}
+
+func TestConfigureTrustsCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	resetConfiguration(t)
+
+	if resp, err := Client().Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected TLS verification error without CA bundle")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o644); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	if err := Configure(Options{CACertFile: bundle}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+
+	resp, err := Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with CA bundle returned error: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestConfigureReadsCABundleFromEnv(t *testing.T) {
+	resetConfiguration(t)
+	t.Setenv(CA_BUNDLE_ENV, filepath.Join(t.TempDir(), "missing.pem"))
+
+	if err := Configure(Options{}); err == nil {
+		t.Fatalf("expected error for missing CA bundle from %s", CA_BUNDLE_ENV)
+	}
+}
+
+func TestConfigureRequiresCertAndKey(t *testing.T) {
+	resetConfiguration(t)
+
+	if err := Configure(Options{ClientCertFile: "client.pem"}); err == nil {
+		t.Fatalf("expected error when client key is missing")
+	}
+}
+
+func resetConfiguration(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		base = nil
+		timeout = 0
+	})
+}
@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MIRROR_CONFIG_ENV is the environment variable pointing at a mirror config file
+const MIRROR_CONFIG_ENV = "NANOLAYER_MIRROR_CONFIG"
+
+// Rewrite replaces the URL prefix From with To
+type Rewrite struct {
+	From string
+	To   string
+}
+
+// ParseRewrite parses a "from=to" prefix rewrite
+func ParseRewrite(value string) (Rewrite, error) {
+	from, to, ok := strings.Cut(value, "=")
+	from = strings.TrimSpace(from)
+	to = strings.TrimSpace(to)
+	if !ok || from == "" || to == "" {
+		return Rewrite{}, fmt.Errorf("invalid mirror rewrite %q, expected 'prefix=replacement'", value)
+	}
+	return Rewrite{From: from, To: to}, nil
+}
+
+// LoadRewrites reads prefix rewrites from a file, one "from=to" per line.
+// Empty lines and lines starting with # are ignored.
+func LoadRewrites(path string) ([]Rewrite, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror config: %w", err)
+	}
+	defer file.Close()
+
+	var rewrites []Rewrite
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rewrite, err := ParseRewrite(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		rewrites = append(rewrites, rewrite)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mirror config: %w", err)
+	}
+	return rewrites, nil
+}
+
+// RewriteURL applies the longest matching prefix rewrite to url
+func RewriteURL(rewrites []Rewrite, url string) string {
+	best := -1
+	for i, rewrite := range rewrites {
+		if !strings.HasPrefix(url, rewrite.From) {
+			continue
+		}
+		if best == -1 || len(rewrite.From) > len(rewrites[best].From) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return url
+	}
+	return rewrites[best].To + strings.TrimPrefix(url, rewrites[best].From)
+}
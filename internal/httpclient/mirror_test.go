@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRewrite(t *testing.T) {
+	rewrite, err := ParseRewrite("https://github.com = https://mirror.local/gh")
+	if err != nil {
+		t.Fatalf("ParseRewrite returned error: %v", err)
+	}
+	if rewrite.From != "https://github.com" || rewrite.To != "https://mirror.local/gh" {
+		t.Fatalf("unexpected rewrite: %+v", rewrite)
+	}
+
+	for _, invalid := range []string{"", "https://github.com", "=https://mirror.local", "https://github.com="} {
+		if _, err := ParseRewrite(invalid); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestLoadRewrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirrors.conf")
+	content := "# internal mirrors\n\nhttps://github.com=https://mirror.local/gh\nhttps://api.github.com=https://mirror.local/api\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	rewrites, err := LoadRewrites(path)
+	if err != nil {
+		t.Fatalf("LoadRewrites returned error: %v", err)
+	}
+	if len(rewrites) != 2 {
+		t.Fatalf("expected 2 rewrites, got %d", len(rewrites))
+	}
+}
+
+func TestRewriteURLUsesLongestPrefix(t *testing.T) {
+	rewrites := []Rewrite{
+		{From: "https://github.com", To: "https://mirror.local/gh"},
+		{From: "https://github.com/cli", To: "https://mirror.local/cli"},
+	}
+
+	tests := map[string]string{
+		"https://github.com/dev/repo/releases/download/v1/tool.tar.gz": "https://mirror.local/gh/dev/repo/releases/download/v1/tool.tar.gz",
+		"https://github.com/cli/cli/releases":                          "https://mirror.local/cli/cli/releases",
+		"https://example.com/other":                                    "https://example.com/other",
+	}
+	for input, want := range tests {
+		if got := RewriteURL(rewrites, input); got != want {
+			t.Fatalf("RewriteURL(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestClientAppliesRewrites(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	previous := Rewrites
+	Rewrites = []Rewrite{{From: "https://github.com", To: server.URL + "/gh"}}
+	t.Cleanup(func() { Rewrites = previous })
+
+	req, err := http.NewRequest(http.MethodGet, "https://github.com/dev/repo/releases", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "token secret")
+
+	resp, err := Client().Do(req)
+	if err != nil {
+		t.Fatalf("request returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotPath != "/gh/dev/repo/releases" {
+		t.Fatalf("expected rewritten path, got %q", gotPath)
+	}
+	if gotAuth != "" {
+		t.Fatalf("expected Authorization header to be dropped for mirror, got %q", gotAuth)
+	}
+}
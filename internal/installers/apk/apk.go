@@ -1,28 +1,60 @@
 package apk
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
 	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+	"github.com/devcontainer-community/nanolayer-go/internal/plan"
 )
 
 func isAlpine() bool {
-	return linuxsystem.Alpine == linuxsystem.GetDistribution()
+	release, err := linuxsystem.GetOSRelease()
+	return err == nil && isAlpineFamily(release)
 }
 
-func InstallPackage(pkg []string) error {
+// isAlpineFamily reports whether release uses apk, like Alpine Linux and
+// distributions derived from it such as Wolfi
+func isAlpineFamily(release *linuxsystem.OSRelease) bool {
+	return release.Family() == linuxsystem.AlpineFamily
+}
+
+func unsupportedDistro() error {
+	return fmt.Errorf("%w: apk requires Alpine Linux or a distribution based on it, found %s", installers.ErrUnsupportedDistro, linuxsystem.GetDistribution())
+}
+
+// InstallPackage installs pkg with apk add --no-cache. When run as root the
+// package cache is backed up beforehand and restored afterwards, also when
+// the installation fails or ctx is canceled, which terminates apk.
+func InstallPackage(ctx context.Context, pkg []string) (err error) {
 	if !isAlpine() {
-		return fmt.Errorf("error: Command only supported on Alpine Linux")
+		return unsupportedDistro()
 	}
 
 	if len(pkg) == 0 {
 		return fmt.Errorf("error: No packages specified")
 	}
 
+	installers.PackageManagerLock.Lock()
+	defer installers.PackageManagerLock.Unlock()
+
+	// Build the command: apk add --no-cache <packages>
+	args := append([]string{"add", "--no-cache"}, pkg...)
+	cmd, err := installers.PrivilegedCommand(ctx, "installing packages with apk", "apk", args...)
+	if err != nil {
+		return err
+	}
+
+	// Without root privileges the cache cannot be backed up and cleaned, it
+	// is left alone as apk add --no-cache does not write to it
+	privileged := linuxsystem.HasRootPrivileges()
+
 	// Create temporary directory and copy /var/cache/apk to it (using native Go)
 	tmpDir, err := os.MkdirTemp("", "apk-cache-*")
 	if err != nil {
@@ -30,53 +62,106 @@ func InstallPackage(pkg []string) error {
 	}
 	defer os.RemoveAll(tmpDir) // Clean up temp directory when done
 
-	cachePath := "/var/cache/apk"
+	cachePath := APK_CACHE_DIR
 	// Check if cache directory exists
-	if _, err := os.Stat(cachePath); err == nil {
+	if _, statErr := os.Stat(cachePath); statErr == nil && privileged {
 		// Copy cache directory to temp location
 		err = linuxsystem.CopyDir(cachePath, filepath.Join(tmpDir, "apk"))
 		if err != nil {
 			return fmt.Errorf("failed to backup APK cache: %w", err)
 		}
+		// Restore the original APK cache however the installation ends
+		defer func() {
+			cleanUp()
+			if restoreErr := linuxsystem.CopyDir(filepath.Join(tmpDir, "apk"), cachePath); restoreErr != nil && err == nil {
+				err = fmt.Errorf("failed to restore APK cache: %w", restoreErr)
+			}
+		}()
 	}
 
-	// Build the command: apk add --no-cache <packages>
-	args := append([]string{"add", "--no-cache"}, pkg...)
-	cmd := exec.Command("apk", args...)
-
 	// Capture output for error reporting
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("installing packages %s: %w", strings.Join(pkg, ", "), ctx.Err())
+	}
 	if err != nil {
 		return fmt.Errorf("failed to install packages %s: %w\nOutput: %s",
 			strings.Join(pkg, ", "), err, string(output))
 	}
 
-	cleanUp()
-	// Restore the original APK cache
-	if _, err := os.Stat(cachePath); err == nil {
-		// Copy back the cache from temp location
-		err = linuxsystem.CopyDir(filepath.Join(tmpDir, "apk"), cachePath)
-		if err != nil {
-			return fmt.Errorf("failed to restore APK cache: %w", err)
-		}
-	}
-
-	fmt.Printf("Successfully installed: %s\n", strings.Join(pkg, ", "))
+	logging.Event(slog.Default(), logging.EventInstall).Info("Installed packages", "packages", pkg)
 	return nil
 }
 
 func cleanUp() error {
 	if !isAlpine() {
-		return fmt.Errorf("error: Command only supported on Alpine Linux")
+		return unsupportedDistro()
 	}
 
 	// Remove the APK cache directory
-	cachePath := "/var/cache/apk"
+	cachePath := APK_CACHE_DIR
 	err := os.RemoveAll(cachePath)
 	if err != nil {
 		return fmt.Errorf("error: Failed to clean up APK cache: %w", err)
 	}
 
-	fmt.Println("Successfully cleaned up APK cache")
+	logging.Event(slog.Default(), logging.EventInstall).Debug("Cleaned up APK cache", "dir", cachePath)
 	return nil
 }
+
+// APK_CACHE_DIR is the package cache that is cleared after installing
+const APK_CACHE_DIR = "/var/cache/apk"
+
+// Plan lists the packages "apk add" would install, including dependencies,
+// using apk's simulation mode so nothing on disk is changed
+func Plan(ctx context.Context, pkg []string) (plan.Step, error) {
+	args := append([]string{"add", "--no-cache"}, pkg...)
+	step := plan.Step{
+		Installer: "apk",
+		Name:      strings.Join(pkg, ", "),
+		Commands:  [][]string{append([]string{"apk"}, args...)},
+		Removes:   []string{APK_CACHE_DIR},
+	}
+
+	if !isAlpine() {
+		return step, unsupportedDistro()
+	}
+	if len(pkg) == 0 {
+		return step, fmt.Errorf("error: No packages specified")
+	}
+
+	output, err := installers.Command(ctx, "apk", append([]string{"add", "--simulate", "--no-cache"}, pkg...)...).CombinedOutput()
+	if err != nil {
+		return step, fmt.Errorf("failed to resolve packages %s: %w\nOutput: %s",
+			strings.Join(pkg, ", "), err, string(output))
+	}
+	step.Packages = parseSimulatedInstall(string(output))
+	return step, nil
+}
+
+// parseSimulatedInstall extracts "name version" from the "(1/3) Installing
+// name (version)" lines printed by apk add --simulate
+func parseSimulatedInstall(output string) []string {
+	var packages []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "(") {
+			continue
+		}
+		_, rest, ok := strings.Cut(line, ") ")
+		if !ok {
+			continue
+		}
+		action, rest, ok := strings.Cut(rest, " ")
+		if !ok || (action != "Installing" && action != "Upgrading") {
+			continue
+		}
+		name, version, _ := strings.Cut(rest, " ")
+		version = strings.Trim(version, "()")
+		if version != "" {
+			name += " " + version
+		}
+		packages = append(packages, name)
+	}
+	return packages
+}
@@ -0,0 +1,51 @@
+package apk
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+func TestParseSimulatedInstall(t *testing.T) {
+	output := `fetch https://dl-cdn.alpinelinux.org/alpine/v3.20/main/x86_64/APKINDEX.tar.gz
+(1/3) Installing ca-certificates (20240705-r0)
+(2/3) Upgrading libcurl (8.9.0-r0 -> 8.9.1-r0)
+(3/3) Installing curl (8.9.1-r0)
+OK: 12 MiB in 17 packages
+`
+	got := parseSimulatedInstall(output)
+	want := []string{"ca-certificates 20240705-r0", "libcurl 8.9.0-r0 -> 8.9.1-r0", "curl 8.9.1-r0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInstallPackageRequiresAlpine(t *testing.T) {
+	if isAlpine() {
+		t.Skip("running on Alpine Linux")
+	}
+	if err := InstallPackage(context.Background(), []string{"curl"}); !errors.Is(err, installers.ErrUnsupportedDistro) {
+		t.Fatalf("expected ErrUnsupportedDistro, got %v", err)
+	}
+}
+
+func TestIsAlpineFamily(t *testing.T) {
+	tests := []struct {
+		release linuxsystem.OSRelease
+		want    bool
+	}{
+		{linuxsystem.OSRelease{ID: "alpine"}, true},
+		{linuxsystem.OSRelease{ID: "wolfi"}, true},
+		{linuxsystem.OSRelease{ID: "postmarketos", IDLike: []string{"alpine"}}, true},
+		{linuxsystem.OSRelease{ID: "debian"}, false},
+	}
+	for _, tt := range tests {
+		if got := isAlpineFamily(&tt.release); got != tt.want {
+			t.Fatalf("isAlpineFamily(%+v) = %v, want %v", tt.release, got, tt.want)
+		}
+	}
+}
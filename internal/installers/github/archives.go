@@ -9,6 +9,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
 	"github.com/dsnet/compress/bzip2"
 )
 
@@ -17,6 +18,10 @@ type ArchiveFile struct {
 	Name    string
 	Content []byte
 	IsDir   bool
+	// Kind classifies the content, see classify
+	Kind FileKind
+	// ELF describes the binary for KindELF entries
+	ELF *elfinspect.Info
 }
 
 // detectArchiveType detects the archive format from URL and magic bytes
@@ -69,8 +74,27 @@ func detectArchiveType(url string, data []byte) string {
 	return "unknown"
 }
 
-// extractArchive extracts files based on archive type
+// Extract detects the archive type of data, downloaded from url or read from
+// a file named url, and returns its classified entries
+func Extract(url string, data []byte) (string, []ArchiveFile, error) {
+	archiveType := detectArchiveType(url, data)
+	files, err := extractArchive(archiveType, data)
+	return archiveType, files, err
+}
+
+// extractArchive extracts files based on archive type and classifies them
 func extractArchive(archiveType string, data []byte) ([]ArchiveFile, error) {
+	files, err := extractEntries(archiveType, data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		classify(&files[i])
+	}
+	return files, nil
+}
+
+func extractEntries(archiveType string, data []byte) ([]ArchiveFile, error) {
 	switch archiveType {
 	case "tar.gz", "tgz":
 		return extractTarGz(data)
@@ -0,0 +1,129 @@
+package github
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// FileKind classifies an archive entry by its content
+type FileKind string
+
+const (
+	KindDirectory FileKind = "directory"
+	// KindELF is an ELF executable or library
+	KindELF FileKind = "elf"
+	// KindScript starts with a #! line
+	KindScript FileKind = "script"
+	KindData   FileKind = "data"
+)
+
+// Qualifiers restricting a file destination pattern to one kind of entry,
+// e.g. "elf:*/tool" only matches ELF binaries for the target architecture
+const (
+	ELF_QUALIFIER    = "elf:"
+	SCRIPT_QUALIFIER = "script:"
+)
+
+// classify sets the kind of file from its content
+func classify(file *ArchiveFile) {
+	switch {
+	case file.IsDir:
+		file.Kind = KindDirectory
+	case bytes.HasPrefix(file.Content, []byte("#!")):
+		file.Kind = KindScript
+	default:
+		file.Kind = KindData
+		if info, err := elfinspect.Inspect(file.Content); err == nil {
+			file.Kind = KindELF
+			file.ELF = info
+		}
+	}
+}
+
+// describe returns the size and kind of file for the archive listing
+func (f ArchiveFile) describe() string {
+	switch f.Kind {
+	case KindELF:
+		return fmt.Sprintf("%d bytes, ELF %s %s", len(f.Content), strings.ToLower(strings.TrimPrefix(f.ELF.Machine.String(), "EM_")), strings.ToLower(strings.TrimPrefix(f.ELF.Type.String(), "ET_")))
+	case KindScript:
+		return fmt.Sprintf("%d bytes, script", len(f.Content))
+	default:
+		return fmt.Sprintf("%d bytes", len(f.Content))
+	}
+}
+
+// isLibrary reports whether an ELF entry is a shared library rather than an
+// executable, judged by its type and name
+func (f ArchiveFile) isLibrary() bool {
+	if f.ELF == nil {
+		return false
+	}
+	return f.ELF.Type == elf.ET_DYN && strings.Contains(path.Base(f.Name), ".so")
+}
+
+// executableFor reports whether f is an ELF executable that runs on arch
+func (f ArchiveFile) executableFor(arch linuxsystem.Architecture) bool {
+	return f.Kind == KindELF && !f.isLibrary() && (f.ELF.Type == elf.ET_EXEC || f.ELF.Type == elf.ET_DYN) && f.ELF.Matches(arch)
+}
+
+// matchDestination reports whether file matches a file destination pattern
+// for arch. Patterns may start with ELF_QUALIFIER or SCRIPT_QUALIFIER; plain
+// patterns match any entry except ELF binaries for another architecture, so
+// multi-platform archives resolve to the right binary.
+func matchDestination(pattern string, file ArchiveFile, arch linuxsystem.Architecture) bool {
+	kind := FileKind("")
+	if rest, ok := strings.CutPrefix(pattern, ELF_QUALIFIER); ok {
+		kind, pattern = KindELF, rest
+	} else if rest, ok := strings.CutPrefix(pattern, SCRIPT_QUALIFIER); ok {
+		kind, pattern = KindScript, rest
+	}
+	if matched, _ := filepath.Match(pattern, file.Name); !matched {
+		return false
+	}
+
+	switch kind {
+	case KindELF:
+		return file.executableFor(arch)
+	case KindScript:
+		return file.Kind == KindScript
+	}
+	return file.Kind != KindELF || file.ELF.Matches(arch)
+}
+
+// onlyExecutable returns the single executable of files for arch: the only
+// ELF executable for arch or, without any, the only script. It fails when
+// there is none or the choice is ambiguous.
+func onlyExecutable(files []ArchiveFile, arch linuxsystem.Architecture) (ArchiveFile, error) {
+	var binaries, scripts []ArchiveFile
+	for _, file := range files {
+		switch {
+		case file.executableFor(arch):
+			binaries = append(binaries, file)
+		case file.Kind == KindScript:
+			scripts = append(scripts, file)
+		}
+	}
+
+	candidates := binaries
+	if len(candidates) == 0 {
+		candidates = scripts
+	}
+	switch len(candidates) {
+	case 0:
+		return ArchiveFile{}, fmt.Errorf("no executable for %s in the archive", arch)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.Name
+	}
+	return ArchiveFile{}, fmt.Errorf("several executables for %s in the archive, choose one with a file destination: %s", arch, strings.Join(names, ", "))
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// elfBinary returns a minimal little endian 64-bit ELF header of the given
+// type (2 executable, 3 shared object) and machine
+func elfBinary(elfType uint16, machine uint16) []byte {
+	header := make([]byte, 64)
+	copy(header, "\x7fELF\x02\x01\x01")
+	binary.LittleEndian.PutUint16(header[16:], elfType)
+	binary.LittleEndian.PutUint16(header[18:], machine)
+	binary.LittleEndian.PutUint32(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[52:], 64)
+	return header
+}
+
+const (
+	machineX86_64  = 62
+	machineAArch64 = 183
+)
+
+func classified(name string, content []byte) ArchiveFile {
+	file := ArchiveFile{Name: name, Content: content}
+	classify(&file)
+	return file
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		file ArchiveFile
+		kind FileKind
+	}{
+		{classified("tool", elfBinary(2, machineX86_64)), KindELF},
+		{classified("install.sh", []byte("#!/bin/sh\n")), KindScript},
+		{classified("README.md", []byte("# tool\n")), KindData},
+		{ArchiveFile{Name: "bin/", IsDir: true}, KindDirectory},
+	}
+	for _, tt := range tests {
+		classify(&tt.file)
+		if tt.file.Kind != tt.kind {
+			t.Fatalf("classify(%s) = %s, want %s", tt.file.Name, tt.file.Kind, tt.kind)
+		}
+	}
+	if got := classified("tool", elfBinary(2, machineAArch64)).describe(); got != "64 bytes, ELF aarch64 exec" {
+		t.Fatalf("unexpected description %q", got)
+	}
+}
+
+func TestMatchDestination(t *testing.T) {
+	amd64 := classified("linux-amd64/tool", elfBinary(2, machineX86_64))
+	arm64 := classified("linux-arm64/tool", elfBinary(2, machineAArch64))
+	script := classified("scripts/tool", []byte("#!/bin/sh\n"))
+
+	tests := []struct {
+		pattern string
+		file    ArchiveFile
+		want    bool
+	}{
+		{"*/tool", amd64, true},
+		{"*/tool", arm64, false},
+		{"*/tool", script, true},
+		{"elf:*/tool", amd64, true},
+		{"elf:*/tool", script, false},
+		{"script:*/tool", script, true},
+		{"script:*/tool", amd64, false},
+		{"elf:*/other", amd64, false},
+	}
+	for _, tt := range tests {
+		if got := matchDestination(tt.pattern, tt.file, linuxsystem.X86_64); got != tt.want {
+			t.Fatalf("matchDestination(%q, %s) = %v, want %v", tt.pattern, tt.file.Name, got, tt.want)
+		}
+	}
+}
+
+func TestOnlyExecutable(t *testing.T) {
+	files := []ArchiveFile{
+		classified("dist/README.md", []byte("# tool\n")),
+		classified("dist/install.sh", []byte("#!/bin/sh\n")),
+		classified("dist/libtool.so.1", elfBinary(3, machineX86_64)),
+		classified("dist/tool-linux-arm64", elfBinary(2, machineAArch64)),
+		classified("dist/tool-linux-amd64", elfBinary(2, machineX86_64)),
+	}
+	file, err := onlyExecutable(files, linuxsystem.X86_64)
+	if err != nil || file.Name != "dist/tool-linux-amd64" {
+		t.Fatalf("expected the amd64 binary, got %q, %v", file.Name, err)
+	}
+
+	// Without a binary for the architecture the only script is chosen
+	file, err = onlyExecutable(files[:3], linuxsystem.X86_64)
+	if err != nil || file.Name != "dist/install.sh" {
+		t.Fatalf("expected the script, got %q, %v", file.Name, err)
+	}
+
+	files = append(files, classified("dist/tool-helper", elfBinary(2, machineX86_64)))
+	if _, err := onlyExecutable(files, linuxsystem.X86_64); err == nil {
+		t.Fatalf("expected an error for several executables")
+	}
+	if _, err := onlyExecutable(files[:1], linuxsystem.X86_64); err == nil {
+		t.Fatalf("expected an error without executables")
+	}
+}
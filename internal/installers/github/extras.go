@@ -0,0 +1,153 @@
+package github
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+)
+
+// SHARE_DIR is where completions and man pages are installed, rebased to
+// ~/.local/share for the user scope
+const SHARE_DIR = "/usr/local/share"
+
+// COMPLETION_SHELLS are the shells completions are installed for
+var COMPLETION_SHELLS = []string{"bash", "zsh", "fish"}
+
+// COMPLETION_TIMEOUT bounds each "<binary> completion <shell>" run, as a
+// binary without that subcommand may start its normal work instead
+const COMPLETION_TIMEOUT = 10 * time.Second
+
+// completionDestination returns where the completion of tool for shell goes
+func completionDestination(tool string, shell string) string {
+	switch shell {
+	case "bash":
+		return filepath.Join(SHARE_DIR, "bash-completion", "completions", tool)
+	case "zsh":
+		return filepath.Join(SHARE_DIR, "zsh", "site-functions", "_"+tool)
+	default:
+		return filepath.Join(SHARE_DIR, "fish", "vendor_completions.d", tool+".fish")
+	}
+}
+
+// DOC_NAMES are documentation files that sit beside completions and man
+// pages without being either
+var DOC_NAMES = []string{"readme", "license", "licence", "copying", "notice", "changelog", "authors"}
+
+// completionShell tells which shell an archive entry is a completion for.
+// Entries qualify by a .bash, .zsh or .fish extension, or by having
+// "complet" in their path and a name completions use: _tool for zsh, bash
+// in the name such as tool.bash-completion, or no extension below a bash,
+// zsh or fish directory.
+func completionShell(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	base := path.Base(lower)
+	ext := path.Ext(base)
+	if slices.Contains(DOC_NAMES, strings.TrimSuffix(base, ext)) {
+		return "", false
+	}
+	switch ext {
+	case ".fish":
+		return "fish", true
+	case ".zsh":
+		return "zsh", true
+	case ".bash":
+		return "bash", true
+	}
+	if !strings.Contains(lower, "complet") {
+		return "", false
+	}
+	switch {
+	case strings.Contains(base, "bash"):
+		return "bash", true
+	case ext != "":
+		return "", false
+	case strings.Contains(lower, "/fish/"):
+		return "fish", true
+	case strings.HasPrefix(base, "_") || strings.Contains(lower, "/zsh/"):
+		return "zsh", true
+	case strings.Contains(lower, "/bash/"):
+		return "bash", true
+	}
+	return "", false
+}
+
+// manPagePattern matches a section suffix not preceded by a digit, so that
+// versioned names such as tool-1.2 are not taken for man pages
+var manPagePattern = regexp.MustCompile(`[^0-9.]\.([1-9])[a-z]*(\.gz)?$`)
+
+// manDirPattern matches the man and man1 to man9 directories
+var manDirPattern = regexp.MustCompile(`^man[1-9]?$`)
+
+// manPageDestination returns where an archive entry named like a man page,
+// e.g. man/tool.1 or doc/tool.5.gz, is installed. Outside a man directory,
+// binaries, scripts and shared libraries such as lib/libfoo.so.1 are not man
+// pages.
+func manPageDestination(file ArchiveFile) (string, bool) {
+	base := path.Base(file.Name)
+	match := manPagePattern.FindStringSubmatch(base)
+	if match == nil || strings.HasPrefix(base, ".") {
+		return "", false
+	}
+	inManDir := manDirPattern.MatchString(path.Base(path.Dir(file.Name)))
+	if !inManDir && (file.Kind == KindELF || file.Kind == KindScript || strings.Contains(base, ".so.")) {
+		return "", false
+	}
+	return filepath.Join(SHARE_DIR, "man", "man"+match[1], base), true
+}
+
+// extraInstallations returns the completions and man pages among files,
+// installed for tool
+func extraInstallations(files []ArchiveFile, tool string, completions bool, manpages bool) []installation {
+	var result []installation
+	seen := map[string]bool{}
+	for _, file := range files {
+		if file.IsDir {
+			continue
+		}
+		destination := ""
+		if shell, ok := completionShell(file.Name); ok && completions {
+			destination = completionDestination(tool, shell)
+		} else if man, ok := manPageDestination(file); ok && manpages {
+			destination = man
+		}
+		if destination != "" && !seen[destination] {
+			seen[destination] = true
+			result = append(result, installation{file: file, destination: destination, mode: 0644})
+		}
+	}
+	return result
+}
+
+// generateCompletions runs "<binary> completion <shell>" for each shell
+// whose completion is missing and installs the output. Each run gets
+// COMPLETION_TIMEOUT and no input, so a binary waiting for either is skipped.
+func generateCompletions(ctx context.Context, binary string, tool string, missing []string, target *installers.Target, log *slog.Logger) {
+	for _, shell := range missing {
+		runCtx, cancel := context.WithTimeout(ctx, COMPLETION_TIMEOUT)
+		cmd := installers.Command(runCtx, binary, "completion", shell)
+		cmd.Stdin = nil
+		output, err := cmd.Output()
+		cancel()
+		if err != nil || len(output) == 0 {
+			log.Info("No completion generated", "shell", shell, "binary", binary)
+			continue
+		}
+		destination := target.Destination(completionDestination(tool, shell))
+		if err := installers.WriteFile(ctx, destination, output, 0644); err != nil {
+			log.Warn("Failed to install completion", "shell", shell, "error", err)
+			continue
+		}
+		if err := target.Chown(destination); err != nil {
+			log.Warn("Failed to install completion", "shell", shell, "error", err)
+			continue
+		}
+		log.Info("Generated completion", "shell", shell, "destination", destination)
+	}
+}
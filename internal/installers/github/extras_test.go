@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+)
+
+func TestCompletionShell(t *testing.T) {
+	tests := []struct {
+		name  string
+		shell string
+		ok    bool
+	}{
+		{"completions/gh.bash", "bash", true},
+		{"tool-1.0/completion/tool.bash-completion", "bash", true},
+		{"autocomplete/_tool", "zsh", true},
+		{"contrib/tool.zsh", "zsh", true},
+		{"completions/fish/tool.fish", "fish", true},
+		{"completions/bash/tool", "bash", true},
+		{"autocomplete/bash_autocomplete", "bash", true},
+		{"tool", "", false},
+		{"README.md", "", false},
+		{"completions/README", "", false},
+		{"completions/README.md", "", false},
+		{"completions/bash/LICENSE", "", false},
+		{"completions/zsh/notes.txt", "", false},
+	}
+	for _, tt := range tests {
+		shell, ok := completionShell(tt.name)
+		if shell != tt.shell || ok != tt.ok {
+			t.Fatalf("completionShell(%q) = %q, %v, want %q, %v", tt.name, shell, ok, tt.shell, tt.ok)
+		}
+	}
+}
+
+func TestManPageDestination(t *testing.T) {
+	tests := []struct {
+		file        ArchiveFile
+		destination string
+		ok          bool
+	}{
+		{ArchiveFile{Name: "man/tool.1"}, "/usr/local/share/man/man1/tool.1", true},
+		{ArchiveFile{Name: "share/man/man8/toold.8", Kind: KindData}, "/usr/local/share/man/man8/toold.8", true},
+		{ArchiveFile{Name: "doc/tool-config.5.gz", Kind: KindData}, "/usr/local/share/man/man5/tool-config.5.gz", true},
+		{ArchiveFile{Name: "tool-1.2"}, "", false},
+		{ArchiveFile{Name: "LICENSE"}, "", false},
+		{ArchiveFile{Name: "lib/libfoo.so.1", Kind: KindELF}, "", false},
+		{ArchiveFile{Name: "lib/libfoo.so.1", Kind: KindData}, "", false},
+		{ArchiveFile{Name: "bin/tool.1", Kind: KindELF}, "", false},
+		{ArchiveFile{Name: "scripts/setup.1", Kind: KindScript}, "", false},
+	}
+	for _, tt := range tests {
+		destination, ok := manPageDestination(tt.file)
+		if destination != tt.destination || ok != tt.ok {
+			t.Fatalf("manPageDestination(%q) = %q, %v, want %q, %v", tt.file.Name, destination, ok, tt.destination, tt.ok)
+		}
+	}
+}
+
+func TestExtraInstallations(t *testing.T) {
+	files := []ArchiveFile{
+		{Name: "tool"},
+		{Name: "completions", IsDir: true},
+		{Name: "completions/tool.bash"},
+		{Name: "completions/_tool"},
+		{Name: "man/tool.1"},
+	}
+
+	got := extraInstallations(files, "tool", true, false)
+	want := map[string]bool{
+		"/usr/local/share/bash-completion/completions/tool": true,
+		"/usr/local/share/zsh/site-functions/_tool":         true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d installations, got %+v", len(want), got)
+	}
+	for _, inst := range got {
+		if !want[inst.destination] || inst.mode != 0644 {
+			t.Fatalf("unexpected installation %s (mode %o)", inst.destination, inst.mode)
+		}
+	}
+
+	got = extraInstallations(files, "tool", false, true)
+	if len(got) != 1 || got[0].destination != "/usr/local/share/man/man1/tool.1" {
+		t.Fatalf("expected only the man page, got %+v", got)
+	}
+}
+
+func TestGenerateCompletions(t *testing.T) {
+	home := t.TempDir()
+	binary := filepath.Join(t.TempDir(), "tool")
+	// Only prints a completion when reading its input ends at once
+	script := "#!/bin/sh\nread line && exit 1\n[ \"$2\" = bash ] && echo 'complete -F _tool tool'\nexit 0\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	target := &installers.Target{Scope: installers.ScopeUser, User: &linuxsystem.User{Home: home}}
+	generateCompletions(context.Background(), binary, "tool", []string{"bash", "zsh"}, target, logging.New(io.Discard))
+
+	bash := filepath.Join(home, ".local", "share", "bash-completion", "completions", "tool")
+	if content, err := os.ReadFile(bash); err != nil || string(content) != "complete -F _tool tool\n" {
+		t.Fatalf("expected generated bash completion, got %q (%v)", string(content), err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "share", "zsh", "site-functions", "_tool")); !os.IsNotExist(err) {
+		t.Fatalf("expected no zsh completion for empty output")
+	}
+}
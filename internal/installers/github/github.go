@@ -1,32 +1,125 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/httpclient"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
 	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+	"github.com/devcontainer-community/nanolayer-go/internal/plan"
+	"github.com/devcontainer-community/nanolayer-go/internal/shellenv"
 )
 
+// ErrRateLimited is returned when the GitHub API refuses requests because
+// the rate limit is exhausted
+var ErrRateLimited = errors.New("GitHub API rate limit exceeded")
+
+// ErrReleaseNotFound is returned when the repository or a release matching
+// the requested version does not exist
+var ErrReleaseNotFound = errors.New("release not found")
+
+// ErrAssetNotFound is returned when the asset URL of a release does not exist
+var ErrAssetNotFound = errors.New("release asset not found")
+
 type Release struct {
-	TagName      string `json:"tag_name"`
-	IsPreRelease bool   `json:"prerelease"`
+	TagName      string  `json:"tag_name"`
+	IsPreRelease bool    `json:"prerelease"`
+	Assets       []Asset `json:"assets"`
 }
 
-func GetGitHubReleases(githubRepo string, allPages bool) ([]Release, error) {
-	// use github api to get list of releases, ordered by release date
-	// use GITHUB_TOKEN env var if available to increase rate limit
+type Asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// GetGitHubReleases returns the releases of githubRepo, newest first: the
+// first page of 30, or with allPages every release
+func GetGitHubReleases(ctx context.Context, githubRepo string, allPages bool) ([]Release, error) {
+	if !allPages {
+		body, _, err := fetchReleasesPage(ctx, githubRepo, releasesURL(githubRepo, 30))
+		if err != nil {
+			return nil, err
+		}
+		releases, _, err := parseReleases(body)
+		return releases, err
+	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases", githubRepo)
+	var releases []Release
+	err := walkReleases(ctx, githubRepo, func(page []Release, _ []byte) bool {
+		releases = append(releases, page...)
+		return false
+	})
+	return releases, err
+}
+
+// RELEASES_PER_PAGE is the page size used when walking releases
+const RELEASES_PER_PAGE = 100
+
+func releasesURL(githubRepo string, perPage int) string {
+	return fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=%d", githubRepo, perPage)
+}
 
-	// Create HTTP request
-	req, err := http.NewRequest("GET", url, nil)
+// walkReleases calls visit with each page of releases of githubRepo, newest
+// first, and with the raw page, following the Link header until visit
+// returns true or no page is left
+func walkReleases(ctx context.Context, githubRepo string, visit func(releases []Release, body []byte) bool) error {
+	url := releasesURL(githubRepo, RELEASES_PER_PAGE)
+	for url != "" {
+		body, next, err := fetchReleasesPage(ctx, githubRepo, url)
+		if err != nil {
+			return err
+		}
+		releases, _, err := parseReleases(body)
+		if err != nil {
+			return err
+		}
+		if visit(releases, body) {
+			return nil
+		}
+		url = next
+	}
+	return nil
+}
+
+// parseReleases decodes a page of releases, returning them with tags
+// trimmed of a leading v and as the raw JSON of each release
+func parseReleases(body []byte) ([]Release, []json.RawMessage, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse releases: %w", err)
+	}
+	releases := make([]Release, len(raw))
+	for i, message := range raw {
+		if err := json.Unmarshal(message, &releases[i]); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse releases: %w", err)
+		}
+		// remove leading v from tag names
+		releases[i].TagName = strings.TrimPrefix(releases[i].TagName, "v")
+	}
+	return releases, raw, nil
+}
+
+// fetchReleasesPage returns the raw GitHub API response at url, a page of
+// the releases of githubRepo, and the URL of the next page if there is one
+func fetchReleasesPage(ctx context.Context, githubRepo string, url string) ([]byte, string, error) {
+	// use GITHUB_TOKEN env var if available to increase rate limit
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add GitHub token if available
@@ -37,44 +130,62 @@ func GetGitHubReleases(githubRepo string, allPages bool) ([]Release, error) {
 	// Set Accept header for GitHub API
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	// Add pagination parameter if allPages is false
-	if !allPages {
-		q := req.URL.Query()
-		q.Add("per_page", "30")
-		req.URL.RawQuery = q.Encode()
-	}
-
 	// Make the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch releases: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, "", releasesStatusError(githubRepo, resp, body)
 	}
 
-	// Parse JSON response
-	var releases []Release
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&releases); err != nil {
-		return nil, fmt.Errorf("failed to parse releases: %w", err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read releases: %w", err)
 	}
+	return body, nextPageURL(resp.Header.Get("Link")), nil
+}
 
-	// remove leading v from tag names
-	for i, release := range releases {
-		releases[i].TagName = strings.TrimPrefix(release.TagName, "v")
+// nextPageURL returns the rel="next" URL of a Link header, "" if there is none
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+			}
+		}
 	}
+	return ""
+}
 
-	return releases, nil
+// releasesStatusError describes a failed GitHub API response, telling rate
+// limits and unknown repositories apart
+func releasesStatusError(githubRepo string, resp *http.Response, body []byte) error {
+	err := fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && (resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""):
+		hint := "set GITHUB_TOKEN to raise the limit"
+		if reset, parseErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+			hint = fmt.Sprintf("resets at %s, %s", time.Unix(reset, 0).UTC().Format(time.RFC3339), hint)
+		}
+		return fmt.Errorf("%w (%s): %w", ErrRateLimited, hint, err)
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: repository %s: %w", ErrReleaseNotFound, githubRepo, err)
+	}
+	return err
 }
 
-func GetLatestRelease(githubRepo string, includePreReleases bool) (*Release, error) {
-	releases, err := GetGitHubReleases(githubRepo, false)
+func GetLatestRelease(ctx context.Context, githubRepo string, includePreReleases bool) (*Release, error) {
+	releases, err := GetGitHubReleases(ctx, githubRepo, false)
 	if err != nil {
 		return nil, err
 	}
@@ -85,28 +196,33 @@ func GetLatestRelease(githubRepo string, includePreReleases bool) (*Release, err
 		}
 	}
 
-	return nil, fmt.Errorf("no suitable release found")
+	return nil, fmt.Errorf("%w: %s has no stable release", ErrReleaseNotFound, githubRepo)
 }
 
-func GetGitHubReleaseAsset(githubRepo string,
+func GetGitHubReleaseAsset(ctx context.Context, githubRepo string,
 	version string,
 	urlTemplate string,
 	templateValues map[string]string) (string, error) {
 
 	if version == "latest" {
-		latestRelease, err := GetLatestRelease(githubRepo, false)
+		if download.Default.Offline {
+			return "", fmt.Errorf("offline mode: an explicit version is required to resolve the asset URL")
+		}
+		latestRelease, err := GetLatestRelease(ctx, githubRepo, false)
 		if err != nil {
 			return "", err
 		}
 		version = latestRelease.TagName
 	}
 
-	// get a release asset URL by replacing template values in the urlTemplate
+	// get a release asset URL by rendering the urlTemplate with the template values
 	templateValues["Version"] = version
-	assetURL := urlTemplate
-	for key, value := range templateValues {
-		placeholder := fmt.Sprintf("${%s}", key)
-		assetURL = strings.ReplaceAll(assetURL, placeholder, value)
+	for key, value := range versionComponents(version) {
+		templateValues[key] = value
+	}
+	assetURL, err := renderAssetURL(urlTemplate, templateValues)
+	if err != nil {
+		return "", err
 	}
 
 	// return the final asset URL
@@ -114,100 +230,430 @@ func GetGitHubReleaseAsset(githubRepo string,
 	if assetURL == "" {
 		return "", fmt.Errorf("failed to generate asset URL")
 	}
+	// in offline mode the asset can only come from the download cache
+	if download.Default.Offline {
+		return assetURL, nil
+	}
 	// check if the assetURL is reachable
-	resp, err := http.Head(assetURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, assetURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to reach asset URL: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s returned status %d", ErrAssetNotFound, assetURL, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("asset %s URL returned status %d", assetURL, resp.StatusCode)
 	}
 	return assetURL, nil
 }
 
-func DownloadAndInstallFromAssetUrl(repo string,
+// DefaultAssetUrlTemplate is used when no asset URL template is given
+const DefaultAssetUrlTemplate = "https://github.com/${Repo}/releases/download/v${Version}/${AssetName}_${Version}_Linux_${Architecture}.tar.gz"
+
+// BIN_DIR is where executables are installed by default
+const BIN_DIR = "/usr/local/bin"
+
+// DefaultFileDestinations installs the file named after the asset to /usr/local/bin
+func DefaultFileDestinations(assetName string) map[string]string {
+	return map[string]string{
+		fmt.Sprintf("*/%s", assetName): fmt.Sprintf("%s/%s", BIN_DIR, assetName),
+	}
+}
+
+// IsLibc reports whether value is a supported ${Libc} value
+func IsLibc(value string) bool {
+	return value == "gnu" || value == "musl"
+}
+
+// InstallOptions describes an asset to download from a GitHub release and
+// where to place its files
+type InstallOptions struct {
+	Repo                     string
+	Version                  string
+	AssetName                string
+	AssetUrlTemplate         string
+	ArchitectureReplacements map[string]string
+	// FileDestinations maps archive entry patterns, optionally qualified
+	// with "elf:" or "script:", to destinations. If empty, the entry named
+	// after the asset or else the only executable goes to /usr/local/bin.
+	FileDestinations map[string]string
+	// Checksum is the expected SHA-256 of the asset, verification is skipped if empty
+	Checksum string
+	// Libc is the ${Libc} template value, "gnu" or "musl", detected if empty
+	Libc string
+	// TargetArchitecture installs the asset for another architecture than
+	// the detected one
+	TargetArchitecture linuxsystem.Architecture
+	// Target rebases file destinations for a user and owns the installed
+	// files to them, destinations are used as given if nil
+	Target *installers.Target
+	// WithCompletions installs the shell completions found in the archive
+	// and generates the missing ones with "<binary> completion <shell>"
+	WithCompletions bool
+	// WithManpages installs the man pages found in the archive
+	WithManpages bool
+	// VerifyCommand is run against the staged files before they are
+	// installed, {bin} is replaced by the path of the tool binary
+	VerifyCommand string
+	// ExpectVersion must appear in the output of VerifyCommand, which
+	// defaults to "{bin} --version" when only ExpectVersion is set
+	ExpectVersion string
+	// AddToPath adds the directories of the installed files to PATH in the
+	// shell startup files of the target
+	AddToPath bool
+	// Output receives log records in the format set by logging.Configure,
+	// the default slog logger is used if nil
+	Output io.Writer
+	// Downloader fetches the asset, download.Default if nil
+	Downloader *download.Downloader
+}
+
+func (opts InstallOptions) logger() *slog.Logger {
+	if opts.Output != nil {
+		return logging.New(opts.Output)
+	}
+	return slog.Default()
+}
+
+func (opts InstallOptions) architecture() linuxsystem.Architecture {
+	if opts.TargetArchitecture != "" {
+		return opts.TargetArchitecture
+	}
+	return linuxsystem.GetArchitecture()
+}
+
+func (opts InstallOptions) downloader() *download.Downloader {
+	if opts.Downloader != nil {
+		return opts.Downloader
+	}
+	return download.Default
+}
+
+func DownloadAndInstallFromAssetUrl(ctx context.Context, repo string,
 	version string,
 	assetName string,
 	assetUrlTemplate string,
 	architectureReplacements map[string]string,
 	fileDestinations map[string]string) error {
 
-	architecture := string(linuxsystem.GetArchitecture())
-	fmt.Printf("Detected architecture: %s\n", architecture)
-	if replacement, ok := architectureReplacements[architecture]; ok {
-		architecture = replacement
-	}
-	fmt.Printf("Using architecture: %s\n", architecture)
-	assetURL, err := GetGitHubReleaseAsset(repo, version, assetUrlTemplate, map[string]string{
-		"Repo":         repo,
-		"Version":      version,
-		"Architecture": architecture,
-		"AssetName":    assetName,
+	_, err := Install(ctx, InstallOptions{
+		Repo:                     repo,
+		Version:                  version,
+		AssetName:                assetName,
+		AssetUrlTemplate:         assetUrlTemplate,
+		ArchitectureReplacements: architectureReplacements,
+		FileDestinations:         fileDestinations,
 	})
-	if err != nil {
-		return fmt.Errorf("failed to get asset URL: %w", err)
+	return err
+}
+
+// ResolveAssetURL renders the asset URL of opts for architecture, applying
+// the architecture replacements, and checks that it is reachable
+func ResolveAssetURL(ctx context.Context, opts InstallOptions, architecture linuxsystem.Architecture) (string, error) {
+	log := logging.Event(opts.logger(), logging.EventResolve)
+	archValue := string(architecture)
+	if replacement, ok := opts.ArchitectureReplacements[archValue]; ok {
+		archValue = replacement
 	}
+	log.Debug("Using architecture", "architecture", archValue)
+	var libc linuxsystem.Libc
+	switch opts.Libc {
+	case "gnu":
+		libc = linuxsystem.Glibc
+	case "musl":
+		libc = linuxsystem.Musl
+	case "":
+		libc, _ = linuxsystem.GetLibc()
+	default:
+		return "", fmt.Errorf("invalid libc %q, expected gnu or musl", opts.Libc)
+	}
+	log.Debug("Using libc", "libc", libc.ABI())
+	aliases := architecture.Aliases()
+	return GetGitHubReleaseAsset(ctx, opts.Repo, opts.Version, opts.AssetUrlTemplate, map[string]string{
+		"Repo":           opts.Repo,
+		"Version":        opts.Version,
+		"Architecture":   archValue,
+		"AssetName":      opts.AssetName,
+		"OS":             "linux",
+		"Distro":         string(linuxsystem.GetDistribution()),
+		"Libc":           libc.ABI(),
+		"GoArch":         aliases.Go,
+		"DebianArch":     aliases.Debian,
+		"RustTarget":     architecture.RustTriple(libc),
+		"DockerPlatform": aliases.Docker,
+	})
+}
 
-	fmt.Printf("Using asset URL %s", assetURL)
+// installation is an archive file and the path it is installed to
+type installation struct {
+	file        ArchiveFile
+	destination string
+	mode        os.FileMode
+}
 
-	// Download the asset
-	resp, err := http.Get(assetURL)
-	if err != nil {
-		return fmt.Errorf("failed to download asset from URL: %w", err)
+// prepared is a resolved and downloaded asset ready to be installed
+type prepared struct {
+	version       string
+	assetURL      string
+	installations []installation
+	// extras are the completions and man pages found in the archive
+	extras []installation
+	// tool names the completions, missingCompletions lists the shells
+	// without a completion in the archive
+	tool               string
+	missingCompletions []string
+}
+
+// resolveRelease resolves latest and version constraints of opts to the
+// exact release that is installed. Offline, latest is left for
+// GetGitHubReleaseAsset to refuse.
+func resolveRelease(ctx context.Context, opts InstallOptions) (string, error) {
+	if opts.Version == "latest" && !download.Default.Offline {
+		release, err := GetLatestRelease(ctx, opts.Repo, false)
+		if err != nil {
+			return "", err
+		}
+		return release.TagName, nil
 	}
-	defer resp.Body.Close()
+	return ResolveVersion(ctx, opts.Repo, opts.Version)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("asset URL returned status %d", resp.StatusCode)
+// prepare resolves the version and asset URL of opts, downloads the asset and
+// matches its files against the file destinations
+func prepare(ctx context.Context, opts InstallOptions) (*prepared, error) {
+	log := opts.logger()
+	resolve := logging.Event(log, logging.EventResolve)
+	version, err := resolveRelease(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve version: %w", err)
+	}
+	if version != opts.Version {
+		resolve.Info("Resolved version", "repo", opts.Repo, "requested", opts.Version, "version", version)
+		opts.Version = version
 	}
 
-	// Read the entire body into memory
-	bodyBytes, err := io.ReadAll(resp.Body)
+	architecture := opts.architecture()
+	resolve.Debug("Using target architecture", "architecture", architecture, "detected", opts.TargetArchitecture == "")
+	assetURL, err := ResolveAssetURL(ctx, opts, architecture)
 	if err != nil {
-		return fmt.Errorf("failed to read asset body: %w", err)
+		return nil, fmt.Errorf("failed to get asset URL: %w", err)
 	}
+	resolve.Info("Resolved asset URL", "repo", opts.Repo, "url", assetURL)
 
-	// Detect archive type
-	archiveType := detectArchiveType(assetURL, bodyBytes)
-	fmt.Printf("Detected archive type: %s\n", archiveType)
+	// Download the asset, reusing the download cache when configured
+	bodyBytes, err := opts.downloader().Fetch(ctx, assetURL, opts.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	logging.Event(log, logging.EventDownload).Info("Downloaded asset", "url", assetURL, "bytes", len(bodyBytes))
 
 	// Extract and list files
+	extract := logging.Event(log, logging.EventExtract)
+	archiveType := detectArchiveType(assetURL, bodyBytes)
 	files, err := extractArchive(archiveType, bodyBytes)
 	if err != nil {
-		return fmt.Errorf("failed to extract archive: %w", err)
+		return nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+	extract.Info("Extracted archive", "type", archiveType, "entries", len(files))
+
+	install := logging.Event(log, logging.EventInstall)
+	if opts.Target != nil {
+		install.Info("Installing for user", "user", opts.Target.User.Name, "scope", opts.Target.Scope)
+	}
+
+	fileDestinations := opts.FileDestinations
+	if len(fileDestinations) == 0 {
+		fileDestinations = DefaultFileDestinations(opts.AssetName)
 	}
 
 	// List the files
-	fmt.Println("Files in archive:")
+	result := &prepared{version: opts.Version, assetURL: assetURL}
 	for _, file := range files {
 		if file.IsDir {
-			fmt.Printf("  %s (directory)\n", file.Name)
-		} else {
-			fmt.Printf("  %s (%d bytes)\n", file.Name, len(file.Content))
-			// Check if there is a destination path for this file
-			for srcFileName, destPath := range fileDestinations {
-				match := false
-				if matched, _ := filepath.Match(srcFileName, file.Name); matched {
-					match = true
-				}
-				if match {
-
-					// Create the destination directory if it doesn't exist
-					err := os.MkdirAll(filepath.Dir(destPath), 0755)
-					if err != nil {
-						return fmt.Errorf("failed to create directory for %s: %w", destPath, err)
-					}
-
-					// Write the file to the destination path
-					err = os.WriteFile(destPath, file.Content, 0755)
-					if err != nil {
-						return fmt.Errorf("failed to write file %s to %s: %w", file.Name, destPath, err)
-					}
-					fmt.Printf("Installed %s to %s\n", file.Name, destPath)
+			extract.Debug("Archive entry", "name", file.Name, "kind", "directory")
+			continue
+		}
+		extract.Debug("Archive entry", "name", file.Name, "kind", file.describe())
+		// Check if there is a destination path for this file
+		for srcFileName, destPath := range fileDestinations {
+			if matchDestination(srcFileName, file, architecture) {
+				destPath = opts.Target.Destination(destPath)
+				result.installations = append(result.installations, installation{file: file, destination: destPath, mode: 0755})
+			}
+		}
+	}
+
+	// Without explicit destinations, fall back to the only executable when
+	// no entry is named after the asset
+	if len(opts.FileDestinations) == 0 && len(result.installations) == 0 {
+		file, err := onlyExecutable(files, architecture)
+		if err != nil {
+			return nil, fmt.Errorf("no file named %s in the archive and %w", opts.AssetName, err)
+		}
+		destPath := opts.Target.Destination(filepath.Join(BIN_DIR, opts.AssetName))
+		install.Info("Using the only executable", "file", file.Name, "name", opts.AssetName)
+		result.installations = append(result.installations, installation{file: file, destination: destPath, mode: 0755})
+	}
+
+	result.tool = result.toolName(opts.AssetName)
+	if opts.WithCompletions || opts.WithManpages {
+		found := map[string]bool{}
+		for _, extra := range extraInstallations(files, result.tool, opts.WithCompletions, opts.WithManpages) {
+			if shell, ok := completionShell(extra.file.Name); ok && opts.WithCompletions {
+				found[shell] = true
+			}
+			extra.destination = opts.Target.Destination(extra.destination)
+			result.extras = append(result.extras, extra)
+		}
+		if opts.WithCompletions {
+			for _, shell := range COMPLETION_SHELLS {
+				if !found[shell] {
+					result.missingCompletions = append(result.missingCompletions, shell)
 				}
 			}
 		}
 	}
+	return result, nil
+}
+
+// toolName returns the name of the installed binary that completions are
+// named after: the one called assetName, or else the first one
+func (p *prepared) toolName(assetName string) string {
+	for _, inst := range p.installations {
+		if filepath.Base(inst.destination) == assetName {
+			return assetName
+		}
+	}
+	if len(p.installations) > 0 {
+		return filepath.Base(p.installations[0].destination)
+	}
+	return assetName
+}
+
+// Install downloads the asset of opts and installs its files. It returns the
+// installed version, with latest and version constraints resolved.
+func Install(ctx context.Context, opts InstallOptions) (string, error) {
+	prepared, err := prepare(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	return prepared.version, installPrepared(ctx, prepared, opts)
+}
+
+// installPrepared writes the files of a prepared asset
+func installPrepared(ctx context.Context, prepared *prepared, opts InstallOptions) error {
+	log := opts.logger()
+	install := logging.Event(log, logging.EventInstall)
+
+	// Catch wrong-architecture and libc mismatches before installing
+	if err := checkBinaries(prepared.installations, opts); err != nil {
+		return err
+	}
+	if err := verify(ctx, prepared, opts, logging.Event(log, logging.EventVerify)); err != nil {
+		return err
+	}
+
+	for _, inst := range append(prepared.installations, prepared.extras...) {
+		// Write the file, creating its directory and escalating through sudo
+		// or doas when the destination is not writable
+		err := installers.WriteFile(ctx, inst.destination, inst.file.Content, inst.mode)
+		if err != nil {
+			return fmt.Errorf("failed to write file %s to %s: %w", inst.file.Name, inst.destination, err)
+		}
+		if err := opts.Target.Chown(inst.destination); err != nil {
+			return err
+		}
+		install.Info("Installed file", "file", inst.file.Name, "destination", inst.destination)
+	}
+
+	if len(prepared.missingCompletions) > 0 {
+		// A binary for another architecture cannot be run to generate them
+		if binary := prepared.binary(); binary != "" && !opts.crossInstall() {
+			generateCompletions(ctx, binary, prepared.tool, prepared.missingCompletions, opts.Target, install)
+		} else if opts.crossInstall() {
+			install.Info("Skipping completion generation for another architecture", "shells", prepared.missingCompletions)
+		}
+	}
+
+	if opts.AddToPath {
+		for _, dir := range prepared.pathDirs() {
+			changed, err := shellenv.Add(ctx, opts.Target, shellenv.PathEntry(dir))
+			if err != nil {
+				return fmt.Errorf("failed to add %s to PATH: %w", dir, err)
+			}
+			for _, file := range changed {
+				install.Info("Added directory to PATH", "dir", dir, "file", file)
+			}
+		}
+	}
 	return nil
 }
+
+// binary returns the installed path of the binary completions are named
+// after, or an empty string if nothing was installed
+func (p *prepared) binary() string {
+	for _, inst := range p.installations {
+		if filepath.Base(inst.destination) == p.tool {
+			return inst.destination
+		}
+	}
+	return ""
+}
+
+// pathDirs returns the directories of the installed files that are not on
+// the default PATH
+func (p *prepared) pathDirs() []string {
+	seen := map[string]bool{}
+	for _, dir := range defaultPath {
+		seen[dir] = true
+	}
+	var dirs []string
+	for _, inst := range p.installations {
+		dir := filepath.Dir(inst.destination)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// defaultPath lists the directories that are on PATH without configuration
+var defaultPath = []string{"/usr/local/sbin", "/usr/local/bin", "/usr/sbin", "/usr/bin", "/sbin", "/bin"}
+
+// Plan resolves and downloads the asset of opts like Install, without writing
+// to the filesystem, and returns the files Install would write
+func Plan(ctx context.Context, opts InstallOptions) (plan.Step, error) {
+	opts.Downloader = opts.downloader().WithReadOnly()
+	step := plan.Step{Installer: "github", Name: opts.Repo, Version: opts.Version}
+
+	prepared, err := prepare(ctx, opts)
+	if err != nil {
+		return step, err
+	}
+	step.Version = prepared.version
+	step.URL = prepared.assetURL
+	for _, inst := range append(prepared.installations, prepared.extras...) {
+		step.Writes = append(step.Writes, inst.destination)
+	}
+	if prepared.binary() != "" {
+		// Completions generated by the installed binary
+		for _, shell := range prepared.missingCompletions {
+			step.Writes = append(step.Writes, opts.Target.Destination(completionDestination(prepared.tool, shell)))
+		}
+	}
+	if opts.AddToPath && len(prepared.pathDirs()) > 0 {
+		for _, file := range shellenv.StartupFiles(opts.Target) {
+			step.Writes = append(step.Writes, file.Path)
+		}
+	}
+	sort.Strings(step.Writes)
+	return step, nil
+}
@@ -2,11 +2,14 @@ package github
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
@@ -29,7 +32,7 @@ func TestGetGitHubReleases_AddsPerPageParameter(t *testing.T) {
 
 	setDefaultTransport(t, transport)
 
-	releases, err := GetGitHubReleases("dev/repo", false)
+	releases, err := GetGitHubReleases(context.Background(), "dev/repo", false)
 	if err != nil {
 		t.Fatalf("GetGitHubReleases returned error: %v", err)
 	}
@@ -44,29 +47,81 @@ func TestGetGitHubReleases_AddsPerPageParameter(t *testing.T) {
 	}
 }
 
-func TestGetGitHubReleases_AllPagesOmitsPerPage(t *testing.T) {
-	var capturedQuery string
-
-	transport := newMockTransport(transportRoute{
+// pagedReleasesRoute serves pages of releases, linking each page to the
+// next, and records the queries it was asked for
+func pagedReleasesRoute(queries *[]string, pages ...string) transportRoute {
+	return transportRoute{
 		match: func(req *http.Request) bool {
 			return req.Method == http.MethodGet && req.URL.Host == "api.github.com" && req.URL.Path == "/repos/dev/repo/releases"
 		},
 		respond: func(req *http.Request) (*http.Response, error) {
-			capturedQuery = req.URL.RawQuery
-			resp := jsonResponse(http.StatusOK, `[{"tag_name":"v1.0.0","prerelease":false}]`)
-			resp.Header.Set("Content-Type", "application/json")
+			*queries = append(*queries, req.URL.RawQuery)
+			page := 1
+			if p := req.URL.Query().Get("page"); p != "" {
+				fmt.Sscanf(p, "%d", &page)
+			}
+			resp := jsonResponse(http.StatusOK, pages[page-1])
+			if page < len(pages) {
+				resp.Header.Set("Link", fmt.Sprintf(`<https://api.github.com/repos/dev/repo/releases?per_page=100&page=%d>; rel="next", <https://api.github.com/repos/dev/repo/releases?per_page=100&page=%d>; rel="last"`, page+1, len(pages)))
+			}
 			return resp, nil
 		},
-	})
+	}
+}
 
-	setDefaultTransport(t, transport)
+func TestGetGitHubReleases_AllPagesFollowsLinkHeader(t *testing.T) {
+	var queries []string
+	setDefaultTransport(t, newMockTransport(pagedReleasesRoute(&queries,
+		`[{"tag_name":"v2.0.0","prerelease":false}]`,
+		`[{"tag_name":"v1.0.0","prerelease":false}]`,
+	)))
 
-	_, err := GetGitHubReleases("dev/repo", true)
+	releases, err := GetGitHubReleases(context.Background(), "dev/repo", true)
 	if err != nil {
 		t.Fatalf("GetGitHubReleases returned error: %v", err)
 	}
-	if capturedQuery != "" {
-		t.Fatalf("expected no query params when allPages=true, got %q", capturedQuery)
+	if len(releases) != 2 || releases[0].TagName != "2.0.0" || releases[1].TagName != "1.0.0" {
+		t.Fatalf("expected releases of both pages, got %+v", releases)
+	}
+	if len(queries) != 2 || queries[0] != "per_page=100" || queries[1] != "per_page=100&page=2" {
+		t.Fatalf("expected the first page and the linked second page, got %q", queries)
+	}
+}
+
+func TestResolveVersion_SearchesLaterPages(t *testing.T) {
+	var queries []string
+	setDefaultTransport(t, newMockTransport(pagedReleasesRoute(&queries,
+		`[{"tag_name":"v2.1.0","prerelease":false},{"tag_name":"v2.0.0","prerelease":false}]`,
+		`[{"tag_name":"v1.4.0","prerelease":false},{"tag_name":"v1.3.0","prerelease":false}]`,
+		`[{"tag_name":"v1.2.0","prerelease":false}]`,
+	)))
+
+	version, err := ResolveVersion(context.Background(), "dev/repo", "^1.0")
+	if err != nil {
+		t.Fatalf("ResolveVersion returned error: %v", err)
+	}
+	if version != "1.4.0" {
+		t.Fatalf("expected 1.4.0 from the second page, got %q", version)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected the walk to stop at the matching page, got %q", queries)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, ""},
+		{`garbage; rel="next"`, ""},
+	}
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.want {
+			t.Fatalf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
 	}
 }
 
@@ -83,9 +138,65 @@ func TestGetGitHubReleases_Non200Status(t *testing.T) {
 
 	setDefaultTransport(t, transport)
 
-	_, err := GetGitHubReleases("dev/repo", false)
-	if err == nil {
-		t.Fatalf("expected error for non-200 response")
+	_, err := GetGitHubReleases(context.Background(), "dev/repo", false)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected rate limit error for 429 response, got %v", err)
+	}
+}
+
+func TestGetGitHubReleases_ClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		status  int
+		headers map[string]string
+		want    error
+	}{
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000000"}, ErrRateLimited},
+		{http.StatusNotFound, nil, ErrReleaseNotFound},
+	}
+	for _, test := range tests {
+		transport := newMockTransport(transportRoute{
+			match: func(req *http.Request) bool { return req.URL.Host == "api.github.com" },
+			respond: func(req *http.Request) (*http.Response, error) {
+				resp := jsonResponse(test.status, `{"message":"error"}`)
+				for key, value := range test.headers {
+					resp.Header.Set(key, value)
+				}
+				return resp, nil
+			},
+		})
+		setDefaultTransport(t, transport)
+
+		_, err := GetGitHubReleases(context.Background(), "dev/repo", false)
+		if !errors.Is(err, test.want) {
+			t.Fatalf("status %d: expected %v, got %v", test.status, test.want, err)
+		}
+	}
+
+	// A plain 403 is not a rate limit
+	transport := newMockTransport(transportRoute{
+		match: func(req *http.Request) bool { return req.URL.Host == "api.github.com" },
+		respond: func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(http.StatusForbidden, `{"message":"forbidden"}`), nil
+		},
+	})
+	setDefaultTransport(t, transport)
+	if _, err := GetGitHubReleases(context.Background(), "dev/repo", false); err == nil || errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected a plain error for 403 without rate limit headers, got %v", err)
+	}
+}
+
+func TestGetGitHubReleaseAsset_NotFound(t *testing.T) {
+	transport := newMockTransport(transportRoute{
+		match: func(req *http.Request) bool { return req.Method == http.MethodHead },
+		respond: func(req *http.Request) (*http.Response, error) {
+			return binaryResponse(http.StatusNotFound, nil), nil
+		},
+	})
+	setDefaultTransport(t, transport)
+
+	_, err := GetGitHubReleaseAsset(context.Background(), "dev/repo", "1.0.0", "https://github.com/${Repo}/releases/download/v${Version}/tool.tar.gz", map[string]string{"Repo": "dev/repo"})
+	if !errors.Is(err, ErrAssetNotFound) {
+		t.Fatalf("expected ErrAssetNotFound, got %v", err)
 	}
 }
 
@@ -104,7 +215,7 @@ func TestGetLatestRelease_SkipsPreReleases(t *testing.T) {
 
 	setDefaultTransport(t, transport)
 
-	release, err := GetLatestRelease("dev/repo", false)
+	release, err := GetLatestRelease(context.Background(), "dev/repo", false)
 	if err != nil {
 		t.Fatalf("GetLatestRelease returned error: %v", err)
 	}
@@ -143,6 +254,7 @@ func TestGetGitHubReleaseAsset_ResolvesLatest(t *testing.T) {
 	setDefaultTransport(t, transport)
 
 	url, err := GetGitHubReleaseAsset(
+		context.Background(),
 		"dev/repo",
 		"latest",
 		"https://downloads/${Repo}/${Version}/${Architecture}/${AssetName}",
@@ -203,6 +315,7 @@ func TestDownloadAndInstallFromAssetUrl(t *testing.T) {
 	setDefaultTransport(t, transport)
 
 	err := DownloadAndInstallFromAssetUrl(
+		context.Background(),
 		"dev/repo",
 		"1.0.0",
 		"tool.tar.gz",
@@ -223,6 +336,89 @@ func TestDownloadAndInstallFromAssetUrl(t *testing.T) {
 	}
 }
 
+func TestPlanDoesNotWriteFiles(t *testing.T) {
+	tarData := createTarArchive(t, []archiveEntry{{name: "bin/tool", body: []byte("payload")}})
+	gzData := compressGzipData(t, tarData)
+
+	arch := string(linuxsystem.GetArchitecture())
+	expectedAssetURL := fmt.Sprintf("https://downloads/1.0.0/%s/tool.tar.gz", arch)
+	destFile := filepath.Join(t.TempDir(), "tool")
+
+	transport := newMockTransport(
+		transportRoute{
+			match: func(req *http.Request) bool {
+				return req.URL.String() == expectedAssetURL
+			},
+			respond: func(req *http.Request) (*http.Response, error) {
+				return binaryResponse(http.StatusOK, gzData), nil
+			},
+		},
+	)
+	setDefaultTransport(t, transport)
+
+	step, err := Plan(context.Background(), InstallOptions{
+		Repo:             "dev/repo",
+		Version:          "1.0.0",
+		AssetName:        "tool.tar.gz",
+		AssetUrlTemplate: "https://downloads/${Version}/${Architecture}/${AssetName}",
+		FileDestinations: map[string]string{"bin/tool": destFile},
+		Output:           io.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	if step.URL != expectedAssetURL || step.Version != "1.0.0" {
+		t.Fatalf("unexpected plan step: %+v", step)
+	}
+	if len(step.Writes) != 1 || step.Writes[0] != destFile {
+		t.Fatalf("expected plan to write %s, got %v", destFile, step.Writes)
+	}
+	if _, err := os.Stat(destFile); !os.IsNotExist(err) {
+		t.Fatalf("expected %s not to be written, got %v", destFile, err)
+	}
+}
+
+func TestResolveAssetURLUsesLibcAndAliases(t *testing.T) {
+	expectedAssetURL := "https://downloads/1.0.0/tool-x86_64-unknown-linux-musl.tar.gz"
+	setDefaultTransport(t, newMockTransport(
+		transportRoute{
+			match: func(req *http.Request) bool {
+				return req.Method == http.MethodHead && req.URL.String() == expectedAssetURL
+			},
+			respond: func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(http.StatusOK, ""), nil
+			},
+		},
+	))
+
+	got, err := ResolveAssetURL(context.Background(), InstallOptions{
+		Repo:             "dev/tool",
+		Version:          "1.0.0",
+		AssetName:        "tool",
+		AssetUrlTemplate: "https://downloads/${Version}/${AssetName}-${RustTarget}.tar.gz",
+		Libc:             "musl",
+		Output:           io.Discard,
+	}, linuxsystem.X86_64)
+	if err != nil {
+		t.Fatalf("ResolveAssetURL returned error: %v", err)
+	}
+	if got != expectedAssetURL {
+		t.Fatalf("ResolveAssetURL = %q, want %q", got, expectedAssetURL)
+	}
+
+	_, err = ResolveAssetURL(context.Background(), InstallOptions{
+		Repo:             "dev/tool",
+		Version:          "1.0.0",
+		AssetUrlTemplate: "https://downloads/${Version}/tool-${Libc}.tar.gz",
+		Libc:             "glibc",
+		Output:           io.Discard,
+	}, linuxsystem.X86_64)
+	if err == nil || !strings.Contains(err.Error(), `invalid libc "glibc"`) {
+		t.Fatalf("expected error for unknown libc, got %v", err)
+	}
+}
+
 type transportRoute struct {
 	match   func(*http.Request) bool
 	respond func(*http.Request) (*http.Response, error)
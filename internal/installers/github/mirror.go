@@ -0,0 +1,143 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+)
+
+// MirrorRelease saves the release metadata of githubRepo and the assets of the
+// selected release into dir. Files are laid out as <dir>/<host>/<path>, so the
+// directory can be served by any static file server and used with mirror
+// rewrites such as https://github.com=http://mirror.local/github.com.
+//
+// Only assets whose names match one of assetPatterns are fetched; all assets
+// are fetched when no pattern is given. It returns the paths written.
+func MirrorRelease(ctx context.Context, githubRepo string, version string, assetPatterns []string, dir string) ([]string, error) {
+	// Walk the pages until the release is found, so older pinned versions
+	// are mirrored too
+	var pages [][]byte
+	var release *Release
+	var selectErr error
+	err := walkReleases(ctx, githubRepo, func(releases []Release, body []byte) bool {
+		pages = append(pages, body)
+		release, selectErr = selectRelease(releases, version)
+		return release != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, fmt.Errorf("%s: %w", githubRepo, selectErr)
+	}
+	body, err := joinReleasePages(pages)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	metadataURL := fmt.Sprintf("https://api.github.com/repos/%s/releases", githubRepo)
+	metadataPath, err := mirrorPath(dir, metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeMirrorFile(metadataPath, body); err != nil {
+		return nil, err
+	}
+	written = append(written, metadataPath)
+
+	for _, asset := range release.Assets {
+		if !matchesAnyPattern(asset.Name, assetPatterns) {
+			continue
+		}
+		content, err := download.Default.Fetch(ctx, asset.BrowserDownloadURL, "")
+		if err != nil {
+			return written, fmt.Errorf("failed to fetch %s: %w", asset.BrowserDownloadURL, err)
+		}
+		assetPath, err := mirrorPath(dir, asset.BrowserDownloadURL)
+		if err != nil {
+			return written, err
+		}
+		if err := writeMirrorFile(assetPath, content); err != nil {
+			return written, err
+		}
+		written = append(written, assetPath)
+	}
+
+	return written, nil
+}
+
+// joinReleasePages combines the walked pages into the single response the
+// mirror serves, keeping a single page verbatim
+func joinReleasePages(pages [][]byte) ([]byte, error) {
+	if len(pages) == 1 {
+		return pages[0], nil
+	}
+	var joined []json.RawMessage
+	for _, page := range pages {
+		_, raw, err := parseReleases(page)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, raw...)
+	}
+	return json.Marshal(joined)
+}
+
+// selectRelease picks the release matching version, or the latest stable
+// release when version is "latest"
+func selectRelease(releases []Release, version string) (*Release, error) {
+	version = strings.TrimPrefix(version, "v")
+	for i, release := range releases {
+		tag := strings.TrimPrefix(release.TagName, "v")
+		if version == "latest" && !release.IsPreRelease {
+			return &releases[i], nil
+		}
+		if tag == version {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w for version %s", ErrReleaseNotFound, version)
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorPath maps a URL to its location inside the mirror directory
+func mirrorPath(dir string, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	cleaned := path.Clean("/" + parsed.Path)
+	if cleaned == "/" {
+		return "", fmt.Errorf("URL %q has no path to mirror", rawURL)
+	}
+	return filepath.Join(dir, parsed.Host, filepath.FromSlash(cleaned)), nil
+}
+
+func writeMirrorFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
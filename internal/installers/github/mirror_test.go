@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirrorRelease(t *testing.T) {
+	payload := `[
+		{"tag_name":"v2.0.0","prerelease":true,"assets":[]},
+		{"tag_name":"v1.0.0","prerelease":false,"assets":[
+			{"name":"tool_linux_amd64.tar.gz","browser_download_url":"https://github.com/dev/repo/releases/download/v1.0.0/tool_linux_amd64.tar.gz"},
+			{"name":"tool_windows_amd64.zip","browser_download_url":"https://github.com/dev/repo/releases/download/v1.0.0/tool_windows_amd64.zip"}
+		]}
+	]`
+
+	transport := newMockTransport(
+		transportRoute{
+			match: func(req *http.Request) bool {
+				return req.URL.Host == "api.github.com" && req.URL.Path == "/repos/dev/repo/releases"
+			},
+			respond: func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(http.StatusOK, payload), nil
+			},
+		},
+		transportRoute{
+			match: func(req *http.Request) bool {
+				return req.URL.String() == "https://github.com/dev/repo/releases/download/v1.0.0/tool_linux_amd64.tar.gz"
+			},
+			respond: func(req *http.Request) (*http.Response, error) {
+				return binaryResponse(http.StatusOK, []byte("linux-asset")), nil
+			},
+		},
+	)
+
+	setDefaultTransport(t, transport)
+
+	dir := t.TempDir()
+	written, err := MirrorRelease(context.Background(), "dev/repo", "latest", []string{"*_linux_*"}, dir)
+	if err != nil {
+		t.Fatalf("MirrorRelease returned error: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("expected metadata and one asset to be written, got %v", written)
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(dir, "api.github.com", "repos", "dev", "repo", "releases"))
+	if err != nil {
+		t.Fatalf("failed to read mirrored metadata: %v", err)
+	}
+	if string(metadata) != payload {
+		t.Fatalf("mirrored metadata does not match API response")
+	}
+
+	asset, err := os.ReadFile(filepath.Join(dir, "github.com", "dev", "repo", "releases", "download", "v1.0.0", "tool_linux_amd64.tar.gz"))
+	if err != nil {
+		t.Fatalf("failed to read mirrored asset: %v", err)
+	}
+	if string(asset) != "linux-asset" {
+		t.Fatalf("unexpected mirrored asset content: %q", string(asset))
+	}
+}
+
+func TestMirrorRelease_PinnedVersionOnLaterPage(t *testing.T) {
+	var queries []string
+	transport := newMockTransport(
+		pagedReleasesRoute(&queries,
+			`[{"tag_name":"v2.0.0","prerelease":false,"assets":[]}]`,
+			`[{"tag_name":"v1.0.0","prerelease":false,"assets":[{"name":"tool_linux_amd64.tar.gz","browser_download_url":"https://github.com/dev/repo/releases/download/v1.0.0/tool_linux_amd64.tar.gz"}]}]`,
+			`[{"tag_name":"v0.9.0","prerelease":false,"assets":[]}]`,
+		),
+		transportRoute{
+			match: func(req *http.Request) bool {
+				return req.URL.Host == "github.com"
+			},
+			respond: func(req *http.Request) (*http.Response, error) {
+				return binaryResponse(http.StatusOK, []byte("linux-asset")), nil
+			},
+		},
+	)
+	setDefaultTransport(t, transport)
+
+	dir := t.TempDir()
+	if _, err := MirrorRelease(context.Background(), "dev/repo", "1.0.0", []string{"*_linux_*"}, dir); err != nil {
+		t.Fatalf("MirrorRelease returned error: %v", err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected the walk to stop at the page with the release, got %q", queries)
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(dir, "api.github.com", "repos", "dev", "repo", "releases"))
+	if err != nil {
+		t.Fatalf("failed to read mirrored metadata: %v", err)
+	}
+	var releases []Release
+	if err := json.Unmarshal(metadata, &releases); err != nil {
+		t.Fatalf("mirrored metadata is not a release list: %v", err)
+	}
+	if len(releases) != 2 || releases[0].TagName != "v2.0.0" || releases[1].TagName != "v1.0.0" {
+		t.Fatalf("expected the releases of both walked pages, got %+v", releases)
+	}
+}
+
+func TestSelectRelease(t *testing.T) {
+	releases := []Release{
+		{TagName: "v2.0.0-rc1", IsPreRelease: true},
+		{TagName: "v1.5.0"},
+	}
+
+	latest, err := selectRelease(releases, "latest")
+	if err != nil || latest.TagName != "v1.5.0" {
+		t.Fatalf("expected latest stable release v1.5.0, got %+v (%v)", latest, err)
+	}
+
+	pinned, err := selectRelease(releases, "v2.0.0-rc1")
+	if err != nil || pinned.TagName != "v2.0.0-rc1" {
+		t.Fatalf("expected pinned release v2.0.0-rc1, got %+v (%v)", pinned, err)
+	}
+
+	if _, err := selectRelease(releases, "3.0.0"); !errors.Is(err, ErrReleaseNotFound) {
+		t.Fatalf("expected error for unknown version")
+	}
+}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs is the curated function set available to Go-template asset URLs
+var templateFuncs = template.FuncMap{
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"title":      capitalize,
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+}
+
+// LIBC_VALUES are the template values that depend on the libc, so a URL
+// using them differs between gnu and musl systems
+var LIBC_VALUES = []string{"Libc", "RustTarget"}
+
+// TemplateUsesLibc reports whether urlTemplate references a libc dependent
+// value, in either template syntax
+func TemplateUsesLibc(urlTemplate string) bool {
+	for _, key := range LIBC_VALUES {
+		if strings.Contains(urlTemplate, "${"+key+"}") || strings.Contains(urlTemplate, "."+key) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderAssetURL renders an asset URL template. Templates containing "{{" use
+// Go text/template syntax with templateFuncs, e.g.
+// {{ .Version | trimPrefix "v" }}; anything else uses ${Key} replacement.
+func renderAssetURL(urlTemplate string, values map[string]string) (string, error) {
+	if !strings.Contains(urlTemplate, "{{") {
+		assetURL := urlTemplate
+		for key, value := range values {
+			placeholder := fmt.Sprintf("${%s}", key)
+			assetURL = strings.ReplaceAll(assetURL, placeholder, value)
+		}
+		return assetURL, nil
+	}
+
+	tmpl, err := template.New("asset-url").
+		Option("missingkey=error").
+		Funcs(templateFuncs).
+		Parse(urlTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid asset URL template: %w", err)
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, values); err != nil {
+		return "", fmt.Errorf("failed to render asset URL template: %w", err)
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+var versionPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// versionComponents returns the Major, Minor and Patch template values of version
+func versionComponents(version string) map[string]string {
+	components := map[string]string{"Major": "", "Minor": "", "Patch": ""}
+	match := versionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return components
+	}
+	components["Major"] = match[1]
+	components["Minor"] = match[2]
+	components["Patch"] = match[3]
+	return components
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
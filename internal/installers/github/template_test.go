@@ -0,0 +1,83 @@
+package github
+
+import "testing"
+
+func TestRenderAssetURL(t *testing.T) {
+	values := map[string]string{
+		"Repo":         "dev/repo",
+		"Version":      "v1.2.3",
+		"Architecture": "arm64",
+		"AssetName":    "tool",
+		"OS":           "linux",
+		"Major":        "1",
+		"Minor":        "2",
+		"Patch":        "3",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "legacy placeholders", template: "https://x/${Repo}/${Version}/${AssetName}_${Architecture}", want: "https://x/dev/repo/v1.2.3/tool_arm64"},
+		{name: "trim prefix", template: `https://x/{{ .AssetName }}_{{ .Version | trimPrefix "v" }}.tar.gz`, want: "https://x/tool_1.2.3.tar.gz"},
+		{name: "title os", template: `{{ .AssetName }}_{{ .OS | title }}`, want: "tool_Linux"},
+		{name: "conditional", template: `{{ .AssetName }}-{{ if eq .Architecture "arm64" }}aarch64.zip{{ else }}x64.tar.gz{{ end }}`, want: "tool-aarch64.zip"},
+		{name: "version components", template: `v{{ .Major }}.{{ .Minor }}/{{ .AssetName }}`, want: "v1.2/tool"},
+		{name: "replace and upper", template: `{{ .Repo | replace "/" "-" | upper }}`, want: "DEV-REPO"},
+		{name: "default", template: `{{ .Patch | default "0" }}`, want: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderAssetURL(tt.template, values)
+			if err != nil {
+				t.Fatalf("renderAssetURL returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("renderAssetURL(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultAssetUrlTemplate(t *testing.T) {
+	// Release assets built with goreleaser, e.g. gum_0.14.0_Linux_arm64.tar.gz
+	got, err := renderAssetURL(DefaultAssetUrlTemplate, map[string]string{
+		"Repo":         "charmbracelet/gum",
+		"Version":      "0.14.0",
+		"AssetName":    "gum",
+		"Architecture": "arm64",
+	})
+	if err != nil {
+		t.Fatalf("renderAssetURL returned error: %v", err)
+	}
+	want := "https://github.com/charmbracelet/gum/releases/download/v0.14.0/gum_0.14.0_Linux_arm64.tar.gz"
+	if got != want {
+		t.Fatalf("default asset URL = %q, want %q", got, want)
+	}
+}
+
+func TestRenderAssetURLErrors(t *testing.T) {
+	if _, err := renderAssetURL("{{ .Version", map[string]string{}); err == nil {
+		t.Fatalf("expected parse error for unterminated action")
+	}
+	if _, err := renderAssetURL("{{ .Unknown }}", map[string]string{"Version": "1.0.0"}); err == nil {
+		t.Fatalf("expected error for unknown variable")
+	}
+}
+
+func TestVersionComponents(t *testing.T) {
+	tests := map[string][3]string{
+		"1.2.3":        {"1", "2", "3"},
+		"v10.4":        {"10", "4", ""},
+		"2.0.0-rc.1":   {"2", "0", "0"},
+		"nightly-2024": {"", "", ""},
+	}
+	for version, want := range tests {
+		got := versionComponents(version)
+		if got["Major"] != want[0] || got["Minor"] != want[1] || got["Patch"] != want[2] {
+			t.Fatalf("versionComponents(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// DEFAULT_VERIFY_COMMAND is run when only an expected version is given
+const DEFAULT_VERIFY_COMMAND = "{bin} --version"
+
+// ErrVerificationFailed is returned when the verification command fails or
+// does not print the expected version
+var ErrVerificationFailed = errors.New("verification failed")
+
+// crossInstall reports whether the asset is installed for another
+// architecture than the one nanolayer runs on, so it cannot be run
+func (opts InstallOptions) crossInstall() bool {
+	return opts.TargetArchitecture != "" && opts.TargetArchitecture != linuxsystem.GetArchitecture()
+}
+
+// checkBinaries checks that the ELF binaries among the installations are
+// built for the target architecture and that their loader is installed
+func checkBinaries(installations []installation, opts InstallOptions) error {
+	root := "/"
+	if opts.crossInstall() {
+		root = ""
+	}
+	for _, inst := range installations {
+		if err := elfinspect.Check(inst.file.Content, opts.architecture(), root); err != nil {
+			return fmt.Errorf("%s: %w", inst.file.Name, err)
+		}
+	}
+	return nil
+}
+
+// verify runs the verification command against a staged copy of the
+// installations, before anything is written to its destination. {bin} in the
+// command is replaced by the staged path of the tool binary.
+func verify(ctx context.Context, p *prepared, opts InstallOptions, log *slog.Logger) error {
+	command := opts.VerifyCommand
+	if command == "" {
+		if opts.ExpectVersion == "" {
+			return nil
+		}
+		command = DEFAULT_VERIFY_COMMAND
+	}
+	if opts.crossInstall() {
+		log.Info("Skipping verification of a cross-architecture install", "architecture", opts.TargetArchitecture)
+		return nil
+	}
+
+	staging, err := os.MkdirTemp("", "nanolayer-verify-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	binary := ""
+	for _, inst := range p.installations {
+		staged := filepath.Join(staging, filepath.Base(inst.destination))
+		if err := os.WriteFile(staged, inst.file.Content, inst.mode); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", inst.file.Name, err)
+		}
+		if filepath.Base(inst.destination) == p.tool || binary == "" {
+			binary = staged
+		}
+	}
+	if binary == "" {
+		return fmt.Errorf("%w: no file to verify", ErrVerificationFailed)
+	}
+
+	command = strings.ReplaceAll(command, "{bin}", "'"+strings.ReplaceAll(binary, "'", `'\''`)+"'")
+	cmd := installers.Command(ctx, "sh", "-c", command)
+	// Let the tool find its sibling files, e.g. helper binaries
+	cmd.Env = append(os.Environ(), "PATH="+staging+string(os.PathListSeparator)+os.Getenv("PATH"))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v\nOutput: %s", ErrVerificationFailed, command, err, string(output))
+	}
+	if expected := strings.TrimPrefix(opts.ExpectVersion, "v"); expected != "" && !containsVersion(string(output), expected) {
+		return fmt.Errorf("%w: expected version %s in output of %s\nOutput: %s", ErrVerificationFailed, expected, command, string(output))
+	}
+	log.Info("Verified", "output", strings.TrimSpace(firstLine(string(output))))
+	return nil
+}
+
+// containsVersion reports whether version appears in output as a whole
+// version, so 1.2 does not match 1.20.3 or v11.2. A trailing dot is allowed
+// when it ends a sentence rather than starting another component.
+func containsVersion(output string, version string) bool {
+	for offset := 0; ; {
+		index := strings.Index(output[offset:], version)
+		if index < 0 {
+			return false
+		}
+		start := offset + index
+		end := start + len(version)
+		before := start == 0 || !isVersionChar(output[start-1])
+		after := end == len(output) || !isDigit(output[end]) &&
+			(output[end] != '.' || end+1 == len(output) || !isDigit(output[end+1]))
+		if before && after {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isVersionChar(c byte) bool {
+	return isDigit(c) || c == '.'
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
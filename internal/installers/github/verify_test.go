@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+)
+
+func scriptPrepared(script string) *prepared {
+	return &prepared{
+		tool: "tool",
+		installations: []installation{
+			{file: ArchiveFile{Name: "bin/tool", Content: []byte(script)}, destination: "/usr/local/bin/tool", mode: 0755},
+		},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	p := scriptPrepared("#!/bin/sh\necho \"tool version 1.2.3\"\n")
+
+	if err := verify(context.Background(), p, InstallOptions{ExpectVersion: "v1.2.3"}, logging.New(io.Discard)); err != nil {
+		t.Fatalf("expected the version to match, got %v", err)
+	}
+	if err := verify(context.Background(), p, InstallOptions{VerifyCommand: "{bin} | grep -q 'tool version'"}, logging.New(io.Discard)); err != nil {
+		t.Fatalf("expected the command to succeed, got %v", err)
+	}
+	if err := verify(context.Background(), p, InstallOptions{ExpectVersion: "1.2"}, logging.New(io.Discard)); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("expected ErrVerificationFailed for a version prefix, got %v", err)
+	}
+	if err := verify(context.Background(), p, InstallOptions{ExpectVersion: "2.0.0"}, logging.New(io.Discard)); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("expected ErrVerificationFailed for a wrong version, got %v", err)
+	}
+	if err := verify(context.Background(), scriptPrepared("#!/bin/sh\nexit 3\n"), InstallOptions{VerifyCommand: "{bin} --help"}, logging.New(io.Discard)); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("expected ErrVerificationFailed for a failing command, got %v", err)
+	}
+}
+
+func TestContainsVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		version string
+		want    bool
+	}{
+		{"tool version 1.2.3", "1.2.3", true},
+		{"tool v1.2 (abc123)", "1.2", true},
+		{"1.2", "1.2", true},
+		{"Version 1.2.", "1.2", true},
+		{"tool 1.20", "1.2", false},
+		{"tool version 1.20.3", "1.2", false},
+		{"tool 1.2.3", "1.2", false},
+		{"tool v11.2", "1.2", false},
+		{"tool 0.1.2", "1.2", false},
+		{"built with go1.22, tool 1.2", "1.2", true},
+	}
+	for _, tt := range tests {
+		if got := containsVersion(tt.output, tt.version); got != tt.want {
+			t.Fatalf("containsVersion(%q, %q) = %v, want %v", tt.output, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCheckBinaries(t *testing.T) {
+	// An ELF header for a machine no supported architecture uses
+	content := []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xff\x00\x01\x00\x00\x00")
+	content = append(content, make([]byte, 40)...)
+	installations := []installation{{file: ArchiveFile{Name: "bin/tool", Content: content}, destination: "/usr/local/bin/tool"}}
+
+	if err := checkBinaries(installations, InstallOptions{TargetArchitecture: "x86_64"}); !errors.Is(err, elfinspect.ErrArchitectureMismatch) {
+		t.Fatalf("expected ErrArchitectureMismatch, got %v", err)
+	}
+	if err := checkBinaries(scriptPrepared("#!/bin/sh\n").installations, InstallOptions{}); err != nil {
+		t.Fatalf("scripts should pass, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semanticVersion is a parsed major.minor.patch version with optional pre-release
+type semanticVersion struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+func parseSemanticVersion(value string) (semanticVersion, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	value, _, _ = strings.Cut(value, "+")
+	core, prerelease, _ := strings.Cut(value, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return semanticVersion{}, false
+	}
+	numbers := [3]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semanticVersion{}, false
+		}
+		numbers[i] = n
+	}
+	return semanticVersion{major: numbers[0], minor: numbers[1], patch: numbers[2], prerelease: prerelease}, true
+}
+
+func (v semanticVersion) compare(other semanticVersion) int {
+	for _, diff := range []int{v.major - other.major, v.minor - other.minor, v.patch - other.patch} {
+		if diff != 0 {
+			return diff
+		}
+	}
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.prerelease, other.prerelease)
+}
+
+// IsVersionConstraint reports whether version is a range such as ">=1.2, <2",
+// "^1.4" or "~1.4.2" rather than "latest" or an exact version
+func IsVersionConstraint(version string) bool {
+	version = strings.TrimSpace(version)
+	if version == "" || version == "latest" {
+		return false
+	}
+	if strings.ContainsAny(version[:1], "<>=!^~*") || strings.Contains(version, ",") {
+		return true
+	}
+	return wildcardPattern.MatchString(version)
+}
+
+var wildcardPattern = regexp.MustCompile(`^v?\d+(\.(\d+|x|\*))*\.(x|\*)$`)
+
+// matchesConstraint reports whether v satisfies every comma separated clause
+// of constraint. Supported operators are =, !=, <, <=, >, >=, ^ and ~, as
+// well as wildcards like 1.4.x or 1.*.
+func matchesConstraint(v semanticVersion, constraint string) (bool, error) {
+	for _, clause := range strings.Split(constraint, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" || clause == "*" {
+			continue
+		}
+
+		operator := ""
+		for _, candidate := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(clause, candidate) {
+				operator = candidate
+				clause = strings.TrimSpace(strings.TrimPrefix(clause, candidate))
+				break
+			}
+		}
+
+		if strings.ContainsAny(clause, "x*") {
+			ok, err := matchesWildcard(v, clause)
+			if err != nil || !ok {
+				return false, err
+			}
+			continue
+		}
+
+		bound, ok := parseSemanticVersion(clause)
+		if !ok {
+			return false, fmt.Errorf("invalid version %q in constraint", clause)
+		}
+		cmp := v.compare(bound)
+
+		var matched bool
+		switch operator {
+		case "", "=":
+			matched = cmp == 0
+		case "!=":
+			matched = cmp != 0
+		case ">":
+			matched = cmp > 0
+		case ">=":
+			matched = cmp >= 0
+		case "<":
+			matched = cmp < 0
+		case "<=":
+			matched = cmp <= 0
+		case "^":
+			// Compatible with bound: same major, or same minor for 0.x versions
+			upper := semanticVersion{major: bound.major + 1}
+			if bound.major == 0 {
+				upper = semanticVersion{minor: bound.minor + 1}
+			}
+			matched = cmp >= 0 && v.compare(upper) < 0
+		case "~":
+			upper := semanticVersion{major: bound.major, minor: bound.minor + 1}
+			matched = cmp >= 0 && v.compare(upper) < 0
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchesWildcard(v semanticVersion, pattern string) (bool, error) {
+	actual := []int{v.major, v.minor, v.patch}
+	for i, part := range strings.Split(strings.TrimPrefix(pattern, "v"), ".") {
+		if i >= len(actual) {
+			return false, fmt.Errorf("invalid version pattern %q", pattern)
+		}
+		if part == "x" || part == "*" {
+			return true, nil
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return false, fmt.Errorf("invalid version pattern %q", pattern)
+		}
+		if n != actual[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ResolveVersion resolves a version constraint to the highest matching stable
+// release of githubRepo. Exact versions and "latest" are returned unchanged.
+func ResolveVersion(ctx context.Context, githubRepo string, version string) (string, error) {
+	if !IsVersionConstraint(version) {
+		return version, nil
+	}
+
+	// Releases come newest first, so stop at the first page with a match
+	// instead of fetching the whole history
+	var best *semanticVersion
+	bestTag := ""
+	var matchErr error
+	err := walkReleases(ctx, githubRepo, func(releases []Release, _ []byte) bool {
+		for _, release := range releases {
+			if release.IsPreRelease {
+				continue
+			}
+			candidate, ok := parseSemanticVersion(release.TagName)
+			if !ok {
+				continue
+			}
+			matched, err := matchesConstraint(candidate, version)
+			if err != nil {
+				matchErr = err
+				return true
+			}
+			if matched && (best == nil || candidate.compare(*best) > 0) {
+				best = &candidate
+				bestTag = release.TagName
+			}
+		}
+		return best != nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if matchErr != nil {
+		return "", matchErr
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("%w: no release of %s matches version constraint %q", ErrReleaseNotFound, githubRepo, version)
+	}
+	return bestTag, nil
+}
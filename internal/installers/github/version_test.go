@@ -0,0 +1,92 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestIsVersionConstraint(t *testing.T) {
+	tests := map[string]bool{
+		"latest":         false,
+		"1.2.3":          false,
+		"v1.2.3-next":    false,
+		">=1.2":          true,
+		"^1.4":           true,
+		"~1.4.2":         true,
+		">=1.0, <2.0":    true,
+		"1.4.x":          true,
+		"1.*":            true,
+		"nightly-x86_64": false,
+	}
+	for version, want := range tests {
+		if got := IsVersionConstraint(version); got != want {
+			t.Fatalf("IsVersionConstraint(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestMatchesConstraint(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"1.4.2", "^1.2", true},
+		{"2.0.0", "^1.2", false},
+		{"0.14.5", "^0.14", true},
+		{"0.15.0", "^0.14", false},
+		{"1.4.9", "~1.4.2", true},
+		{"1.5.0", "~1.4.2", false},
+		{"1.9.0", ">=1.0, <2.0", true},
+		{"2.0.0", ">=1.0, <2.0", false},
+		{"1.4.7", "1.4.x", true},
+		{"1.5.0", "1.4.x", false},
+		{"2.0.0-rc.1", ">=2.0.0", false},
+		{"1.2.3", "!=1.2.3", false},
+	}
+	for _, tt := range tests {
+		v, ok := parseSemanticVersion(tt.version)
+		if !ok {
+			t.Fatalf("failed to parse %q", tt.version)
+		}
+		got, err := matchesConstraint(v, tt.constraint)
+		if err != nil {
+			t.Fatalf("matchesConstraint(%q, %q) returned error: %v", tt.version, tt.constraint, err)
+		}
+		if got != tt.want {
+			t.Fatalf("matchesConstraint(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	transport := newMockTransport(transportRoute{
+		match: func(req *http.Request) bool {
+			return req.URL.Host == "api.github.com" && req.URL.Path == "/repos/dev/repo/releases"
+		},
+		respond: func(req *http.Request) (*http.Response, error) {
+			payload := `[{"tag_name":"v2.0.0","prerelease":false},{"tag_name":"v1.9.0-rc1","prerelease":true},{"tag_name":"v1.8.1","prerelease":false},{"tag_name":"v1.7.0","prerelease":false}]`
+			return jsonResponse(http.StatusOK, payload), nil
+		},
+	})
+
+	setDefaultTransport(t, transport)
+
+	got, err := ResolveVersion(context.Background(), "dev/repo", "^1.7")
+	if err != nil {
+		t.Fatalf("ResolveVersion returned error: %v", err)
+	}
+	if got != "1.8.1" {
+		t.Fatalf("expected 1.8.1, got %q", got)
+	}
+
+	if exact, err := ResolveVersion(context.Background(), "dev/repo", "1.7.0"); err != nil || exact != "1.7.0" {
+		t.Fatalf("expected exact version to pass through, got %q (%v)", exact, err)
+	}
+
+	if _, err := ResolveVersion(context.Background(), "dev/repo", "^3"); !errors.Is(err, ErrReleaseNotFound) {
+		t.Fatalf("expected error when no release matches")
+	}
+}
@@ -1 +1,34 @@
 package installers
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrUnsupportedDistro is returned by package installers run on a
+// distribution they do not support
+var ErrUnsupportedDistro = errors.New("unsupported distribution")
+
+// PackageManagerLock serializes package manager invocations, which share a
+// package database and cache and must never run concurrently
+var PackageManagerLock sync.Mutex
+
+// TERMINATION_GRACE is how long a child process may take to exit after
+// SIGTERM before it is killed
+const TERMINATION_GRACE = 10 * time.Second
+
+// Command returns a command running name with args that receives SIGTERM
+// when ctx is done, and SIGKILL if it has not exited TERMINATION_GRACE later,
+// so package managers can release their locks
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = TERMINATION_GRACE
+	return cmd
+}
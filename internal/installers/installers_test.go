@@ -0,0 +1,22 @@
+package installers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCommandTerminatedOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	// The shell exits on SIGTERM after running its trap
+	err := Command(ctx, "sh", "-c", "trap 'exit 143' TERM; sleep 10 & wait").Run()
+	if err == nil {
+		t.Fatalf("expected the command to be terminated")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("command was not terminated in time, took %s", elapsed)
+	}
+}
@@ -0,0 +1,90 @@
+package installers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// ErrPrivilegesRequired is returned when a step needs root privileges and
+// neither sudo nor doas can provide them without a password
+var ErrPrivilegesRequired = errors.New("root privileges required")
+
+// ESCALATION_TOOLS are tried in order to run privileged steps as root
+var ESCALATION_TOOLS = []string{"sudo", "doas"}
+
+// lookPath and canEscalate are replaced in tests
+var (
+	lookPath    = exec.LookPath
+	canEscalate = func(tool string) bool {
+		// -n fails instead of prompting when a password would be needed
+		return exec.Command(tool, "-n", "true").Run() == nil
+	}
+)
+
+// PrivilegedCommand returns a command running name with args as root. Without
+// root privileges the command is run through "sudo -n" or "doas -n"; if
+// neither is usable without a password, the error explains what step needs.
+// The command is terminated when ctx is done, see Command.
+func PrivilegedCommand(ctx context.Context, step string, name string, args ...string) (*exec.Cmd, error) {
+	if linuxsystem.HasRootPrivileges() {
+		return Command(ctx, name, args...), nil
+	}
+	prefix, err := escalationPrefix()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", step, err)
+	}
+	return Command(ctx, prefix[0], append(append(prefix[1:], name), args...)...), nil
+}
+
+// escalationPrefix returns the command prefix that runs a command as root
+func escalationPrefix() ([]string, error) {
+	for _, tool := range ESCALATION_TOOLS {
+		if _, err := lookPath(tool); err != nil {
+			continue
+		}
+		if canEscalate(tool) {
+			return []string{tool, "-n"}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: run nanolayer as root, or allow the current user to use sudo or doas without a password", ErrPrivilegesRequired)
+}
+
+// WriteFile writes content to path like os.WriteFile, creating the parent
+// directories. When the current user may not write there, the file is
+// staged in a temporary directory and installed through sudo or doas.
+func WriteFile(ctx context.Context, path string, content []byte, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = os.WriteFile(path, content, mode)
+	}
+	if err == nil || !errors.Is(err, fs.ErrPermission) || linuxsystem.HasRootPrivileges() {
+		return err
+	}
+
+	staging, err := os.MkdirTemp("", "nanolayer-staging-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	staged := filepath.Join(staging, filepath.Base(path))
+	if err := os.WriteFile(staged, content, mode); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", path, err)
+	}
+
+	cmd, err := PrivilegedCommand(ctx, "writing "+path, "install", "-D", "-m", strconv.FormatUint(uint64(mode.Perm()), 8), staged, path)
+	if err != nil {
+		return err
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install %s: %w\nOutput: %s", path, err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package installers
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func stubEscalation(t *testing.T, installed []string, usable []string) {
+	t.Helper()
+	previousLookPath, previousCanEscalate := lookPath, canEscalate
+	t.Cleanup(func() {
+		lookPath, canEscalate = previousLookPath, previousCanEscalate
+	})
+
+	contains := func(list []string, value string) bool {
+		for _, item := range list {
+			if item == value {
+				return true
+			}
+		}
+		return false
+	}
+	lookPath = func(file string) (string, error) {
+		if contains(installed, file) {
+			return "/usr/bin/" + file, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	canEscalate = func(tool string) bool { return contains(usable, tool) }
+}
+
+func TestEscalationPrefix(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string
+		usable    []string
+		want      []string
+	}{
+		{"sudo", []string{"sudo", "doas"}, []string{"sudo", "doas"}, []string{"sudo", "-n"}},
+		{"sudo needs password", []string{"sudo", "doas"}, []string{"doas"}, []string{"doas", "-n"}},
+		{"doas only", []string{"doas"}, []string{"doas"}, []string{"doas", "-n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubEscalation(t, tt.installed, tt.usable)
+			got, err := escalationPrefix()
+			if err != nil {
+				t.Fatalf("escalationPrefix returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("escalationPrefix = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscalationPrefixUnavailable(t *testing.T) {
+	stubEscalation(t, []string{"sudo"}, nil)
+	_, err := escalationPrefix()
+	if !errors.Is(err, ErrPrivilegesRequired) {
+		t.Fatalf("expected ErrPrivilegesRequired, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package installers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// Scope decides whether files are installed for all users or for one user
+type Scope string
+
+const (
+	// ScopeSystem installs below /usr/local
+	ScopeSystem Scope = "system"
+	// ScopeUser installs below ~/.local of the target user
+	ScopeUser Scope = "user"
+)
+
+// SYSTEM_PREFIX is the prefix of system-wide destinations, rebased onto
+// USER_PREFIX in the user's home for the user scope
+const SYSTEM_PREFIX = "/usr/local/"
+
+// USER_PREFIX is the location of user installs relative to the home directory
+const USER_PREFIX = ".local/"
+
+// Target is where an installer places files and who owns them
+type Target struct {
+	Scope Scope
+	User  *linuxsystem.User
+}
+
+// ResolveTarget builds the target for scope and userName. An empty userName
+// selects the devcontainer remote user (see linuxsystem.ResolveTargetUser);
+// an empty scope selects the system scope with root privileges and the user
+// scope without.
+func ResolveTarget(scope string, userName string) (*Target, error) {
+	var user *linuxsystem.User
+	var err error
+	if userName != "" {
+		user, err = linuxsystem.LookupUser(userName)
+	} else {
+		user, err = linuxsystem.ResolveTargetUser()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve target user: %w", err)
+	}
+
+	target := &Target{Scope: Scope(scope), User: user}
+	switch target.Scope {
+	case ScopeSystem, ScopeUser:
+	case "":
+		target.Scope = ScopeSystem
+		if !linuxsystem.HasRootPrivileges() {
+			target.Scope = ScopeUser
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scope %q, expected system or user", scope)
+	}
+	return target, nil
+}
+
+// ResolveTargetOrDefault is ResolveTarget for command line flags: when
+// neither scope nor userName is given and no user can be resolved, it
+// returns a nil target so destinations are used as given
+func ResolveTargetOrDefault(scope string, userName string) (*Target, error) {
+	target, err := ResolveTarget(scope, userName)
+	if err != nil && scope == "" && userName == "" {
+		return nil, nil
+	}
+	return target, err
+}
+
+// Destination maps a destination path to its location for the target: "~/"
+// expands to the user's home and, for the user scope, paths below /usr/local/
+// are moved to ~/.local/. A nil target returns path unchanged.
+func (t *Target) Destination(path string) string {
+	if t == nil || t.User == nil {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(t.User.Home, rest)
+	}
+	if rest, ok := strings.CutPrefix(path, SYSTEM_PREFIX); ok && t.Scope == ScopeUser {
+		return filepath.Join(t.User.Home, USER_PREFIX, rest)
+	}
+	return path
+}
+
+// Chown hands path, and the directories leading to it from the user's home,
+// to the target user when it lies inside their home. It does nothing without
+// root privileges or for files outside the home directory.
+func (t *Target) Chown(path string) error {
+	if t == nil || t.User == nil || os.Geteuid() != 0 || t.User.UID == 0 {
+		return nil
+	}
+	home := filepath.Clean(t.User.Home)
+	relative, err := filepath.Rel(home, path)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return nil
+	}
+
+	for current := path; current != home; current = filepath.Dir(current) {
+		if err := os.Lchown(current, t.User.UID, t.User.GID); err != nil {
+			return fmt.Errorf("failed to change owner of %s: %w", current, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package installers
+
+import (
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+func TestTargetDestination(t *testing.T) {
+	user := &linuxsystem.User{Name: "vscode", UID: 1000, GID: 1000, Home: "/home/vscode"}
+	tests := []struct {
+		target *Target
+		path   string
+		want   string
+	}{
+		{nil, "/usr/local/bin/tool", "/usr/local/bin/tool"},
+		{&Target{Scope: ScopeSystem, User: user}, "/usr/local/bin/tool", "/usr/local/bin/tool"},
+		{&Target{Scope: ScopeUser, User: user}, "/usr/local/bin/tool", "/home/vscode/.local/bin/tool"},
+		{&Target{Scope: ScopeUser, User: user}, "/usr/local/share/man/man1/tool.1", "/home/vscode/.local/share/man/man1/tool.1"},
+		{&Target{Scope: ScopeUser, User: user}, "/opt/tool/bin/tool", "/opt/tool/bin/tool"},
+		{&Target{Scope: ScopeSystem, User: user}, "~/.config/tool/config.yaml", "/home/vscode/.config/tool/config.yaml"},
+	}
+	for _, tt := range tests {
+		if got := tt.target.Destination(tt.path); got != tt.want {
+			t.Fatalf("Destination(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveTargetRejectsUnknownScope(t *testing.T) {
+	if _, err := ResolveTarget("global", "root"); err == nil {
+		t.Fatalf("expected error for unknown scope")
+	}
+}
@@ -0,0 +1,185 @@
+package linuxsystem
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// CPUINFO_FILE is read to tell ARM variants apart on 32-bit ARM kernels
+const CPUINFO_FILE = "/proc/cpuinfo"
+
+// ArchitectureAliases are the names other ecosystems use for an architecture
+type ArchitectureAliases struct {
+	// Go is the GOARCH value, with the GOARM variant in GoArm for 32-bit ARM
+	Go    string
+	GoArm string
+	// Debian is the dpkg architecture name
+	Debian string
+	// Rust is the first component of the Rust target triple
+	Rust string
+	// Docker is the OCI platform, e.g. linux/arm/v7
+	Docker string
+}
+
+// architectures lists the canonical architectures with their aliases. The
+// order decides which architecture an ambiguous alias such as GOARCH "arm"
+// resolves to.
+var architectures = []struct {
+	arch    Architecture
+	aliases ArchitectureAliases
+}{
+	{X86_64, ArchitectureAliases{Go: "amd64", Debian: "amd64", Rust: "x86_64", Docker: "linux/amd64"}},
+	{ARM64, ArchitectureAliases{Go: "arm64", Debian: "arm64", Rust: "aarch64", Docker: "linux/arm64"}},
+	{ARMV7, ArchitectureAliases{Go: "arm", GoArm: "7", Debian: "armhf", Rust: "armv7", Docker: "linux/arm/v7"}},
+	{ARMHF, ArchitectureAliases{Go: "arm", GoArm: "7", Debian: "armhf", Rust: "armv7", Docker: "linux/arm/v7"}},
+	{ARM32, ArchitectureAliases{Go: "arm", GoArm: "7", Debian: "armhf", Rust: "armv7", Docker: "linux/arm"}},
+	{ARMV6, ArchitectureAliases{Go: "arm", GoArm: "6", Debian: "armel", Rust: "arm", Docker: "linux/arm/v6"}},
+	{ARMV5, ArchitectureAliases{Go: "arm", GoArm: "5", Debian: "armel", Rust: "armv5te", Docker: "linux/arm/v5"}},
+	{I386, ArchitectureAliases{Go: "386", Debian: "i386", Rust: "i686", Docker: "linux/386"}},
+	{I686, ArchitectureAliases{Go: "386", Debian: "i386", Rust: "i686", Docker: "linux/386"}},
+	{PPC64LE, ArchitectureAliases{Go: "ppc64le", Debian: "ppc64el", Rust: "powerpc64le", Docker: "linux/ppc64le"}},
+	{PPC64, ArchitectureAliases{Go: "ppc64", Debian: "ppc64", Rust: "powerpc64", Docker: "linux/ppc64"}},
+	{S390, ArchitectureAliases{Go: "s390x", Debian: "s390x", Rust: "s390x", Docker: "linux/s390x"}},
+	{RISCV64, ArchitectureAliases{Go: "riscv64", Debian: "riscv64", Rust: "riscv64gc", Docker: "linux/riscv64"}},
+	{LOONGARCH64, ArchitectureAliases{Go: "loong64", Debian: "loong64", Rust: "loongarch64", Docker: "linux/loong64"}},
+	{MIPS64LE, ArchitectureAliases{Go: "mips64le", Debian: "mips64el", Rust: "mips64el", Docker: "linux/mips64le"}},
+	{MIPS64, ArchitectureAliases{Go: "mips64", Debian: "mips64", Rust: "mips64", Docker: "linux/mips64"}},
+}
+
+// Aliases returns the names other ecosystems use for a, empty for OTHER
+func (a Architecture) Aliases() ArchitectureAliases {
+	for _, entry := range architectures {
+		if entry.arch == a {
+			return entry.aliases
+		}
+	}
+	return ArchitectureAliases{}
+}
+
+// RustTriple returns the Rust target triple of a for the given libc, e.g.
+// x86_64-unknown-linux-musl or armv7-unknown-linux-gnueabihf
+func (a Architecture) RustTriple(libc Libc) string {
+	rust := a.Aliases().Rust
+	if rust == "" {
+		return ""
+	}
+	abi := libc.ABI()
+	if abi == "" {
+		abi = "gnu"
+	}
+	switch a {
+	case ARMV7, ARMHF, ARM32, ARMV6:
+		abi += "eabihf"
+	case ARMV5:
+		abi += "eabi"
+	case MIPS64, MIPS64LE:
+		abi += "abi64"
+	}
+	return rust + "-unknown-linux-" + abi
+}
+
+// ParseArchitecture resolves a canonical name, uname machine, GOARCH, Debian
+// architecture, Rust triple or Docker platform to an architecture
+func ParseArchitecture(value string) (Architecture, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return OTHER, false
+	}
+	for _, entry := range architectures {
+		if string(entry.arch) == value {
+			return entry.arch, true
+		}
+	}
+	if arch := architectureFromMachine(value, nil); arch != OTHER {
+		return arch, true
+	}
+
+	rust, _, isTriple := strings.Cut(value, "-unknown-linux")
+	for _, entry := range architectures {
+		aliases := entry.aliases
+		switch {
+		case isTriple && aliases.Rust == rust:
+			return entry.arch, true
+		case value == aliases.Go, value == aliases.Debian, value == aliases.Docker:
+			return entry.arch, true
+		}
+	}
+	return OTHER, false
+}
+
+// ArchitectureFromMachine maps a uname machine string to an architecture,
+// reading /proc/cpuinfo for the variant of generic 32-bit ARM machines
+func ArchitectureFromMachine(machine string) Architecture {
+	return architectureFromMachine(machine, readCPUArchitecture)
+}
+
+func architectureFromMachine(machine string, cpuArchitecture func() string) Architecture {
+	switch machine {
+	case "arm64", "aarch64", "aarch64_be":
+		return ARM64
+	case "x86_64", "amd64":
+		return X86_64
+	case "armv5", "armv5l", "armv5tel", "armv5tejl":
+		return ARMV5
+	case "armv6", "armv6l":
+		return ARMV6
+	case "armv7", "armv7l":
+		return ARMV7
+	case "armhf":
+		return ARMHF
+	case "arm32":
+		return ARM32
+	case "arm", "armv8l":
+		// Generic names, e.g. a 32-bit userland on a 64-bit kernel
+		if cpuArchitecture != nil {
+			switch cpuArchitecture() {
+			case "5", "5TE", "5TEJ":
+				return ARMV5
+			case "6", "6TEJ":
+				return ARMV6
+			case "7", "8":
+				return ARMV7
+			}
+		}
+		return ARM32
+	case "i386":
+		return I386
+	case "i686":
+		return I686
+	case "ppc64":
+		return PPC64
+	case "ppc64le":
+		return PPC64LE
+	case "s390", "s390x":
+		return S390
+	case "riscv64":
+		return RISCV64
+	case "loongarch64":
+		return LOONGARCH64
+	case "mips64":
+		return MIPS64
+	case "mips64el", "mips64le":
+		return MIPS64LE
+	default:
+		return OTHER
+	}
+}
+
+// readCPUArchitecture returns the "CPU architecture" field of /proc/cpuinfo
+func readCPUArchitecture() string {
+	file, err := os.Open(CPUINFO_FILE)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "CPU architecture" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
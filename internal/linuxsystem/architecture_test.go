@@ -0,0 +1,83 @@
+package linuxsystem
+
+import "testing"
+
+func TestArchitectureFromMachine(t *testing.T) {
+	tests := map[string]Architecture{
+		"x86_64":      X86_64,
+		"aarch64":     ARM64,
+		"armv7l":      ARMV7,
+		"armv6l":      ARMV6,
+		"armv5tel":    ARMV5,
+		"ppc64":       PPC64,
+		"ppc64le":     PPC64LE,
+		"s390x":       S390,
+		"riscv64":     RISCV64,
+		"loongarch64": LOONGARCH64,
+		"mips64":      MIPS64,
+		"mips64el":    MIPS64LE,
+		"sparc64":     OTHER,
+	}
+	for machine, want := range tests {
+		if got := architectureFromMachine(machine, nil); got != want {
+			t.Fatalf("architectureFromMachine(%q) = %q, want %q", machine, got, want)
+		}
+	}
+}
+
+func TestArchitectureFromMachineReadsARMVariant(t *testing.T) {
+	tests := map[string]Architecture{"7": ARMV7, "8": ARMV7, "6TEJ": ARMV6, "5TE": ARMV5, "": ARM32}
+	for variant, want := range tests {
+		got := architectureFromMachine("armv8l", func() string { return variant })
+		if got != want {
+			t.Fatalf("CPU architecture %q: got %q, want %q", variant, got, want)
+		}
+	}
+}
+
+func TestParseArchitecture(t *testing.T) {
+	tests := map[string]Architecture{
+		"x86_64":                        X86_64,
+		"amd64":                         X86_64,
+		"linux/amd64":                   X86_64,
+		"aarch64-unknown-linux-musl":    ARM64,
+		"arm64":                         ARM64,
+		"armhf":                         ARMHF,
+		"linux/arm/v7":                  ARMV7,
+		"armv7-unknown-linux-gnueabihf": ARMV7,
+		"armel":                         ARMV6,
+		"ppc64el":                       PPC64LE,
+		"loong64":                       LOONGARCH64,
+		"riscv64gc-unknown-linux-gnu":   RISCV64,
+		"386":                           I386,
+	}
+	for value, want := range tests {
+		got, ok := ParseArchitecture(value)
+		if !ok || got != want {
+			t.Fatalf("ParseArchitecture(%q) = %q (%v), want %q", value, got, ok, want)
+		}
+	}
+	if _, ok := ParseArchitecture("sparc"); ok {
+		t.Fatalf("expected unknown architecture not to parse")
+	}
+}
+
+func TestRustTriple(t *testing.T) {
+	tests := []struct {
+		arch Architecture
+		libc Libc
+		want string
+	}{
+		{X86_64, Glibc, "x86_64-unknown-linux-gnu"},
+		{ARM64, Musl, "aarch64-unknown-linux-musl"},
+		{ARMV7, Glibc, "armv7-unknown-linux-gnueabihf"},
+		{ARMV7, Musl, "armv7-unknown-linux-musleabihf"},
+		{RISCV64, UnknownLibc, "riscv64gc-unknown-linux-gnu"},
+		{OTHER, Glibc, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.arch.RustTriple(tt.libc); got != tt.want {
+			t.Fatalf("%s.RustTriple(%s) = %q, want %q", tt.arch, tt.libc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package linuxsystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FEATURES_INSTALL_DIR is where the devcontainer CLI copies features while
+// installing them during an image build, it is removed afterwards
+const FEATURES_INSTALL_DIR = "/tmp/dev-container-features"
+
+// Environment describes where nanolayer is running
+type Environment struct {
+	// ContainerRuntime is docker, podman, containerd, kubernetes, lxc or
+	// the value of $container, empty outside of a container
+	ContainerRuntime string
+	// ImageBuild is set while building an image, e.g. when a devcontainer
+	// feature is installed, as opposed to inside a running container
+	ImageBuild bool
+	// Devcontainer is set inside a running devcontainer
+	Devcontainer bool
+	Codespaces   bool
+	WSL          bool
+	// Systemd is set when systemd is the running init system
+	Systemd bool
+}
+
+// InContainer reports whether a container runtime was detected
+func (e Environment) InContainer() bool {
+	return e.ContainerRuntime != ""
+}
+
+// DetectEnvironment inspects the running system
+func DetectEnvironment() Environment {
+	return detectEnvironment("/", os.Getenv)
+}
+
+// GetContainerRuntime returns the container runtime nanolayer runs in, or an
+// empty string outside of a container
+func GetContainerRuntime() string {
+	return DetectEnvironment().ContainerRuntime
+}
+
+// detectEnvironment inspects the system rooted at root with getenv as its
+// environment, so tests can use fixtures
+func detectEnvironment(root string, getenv func(string) string) Environment {
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(root, path))
+		return err == nil
+	}
+	read := func(path string) string {
+		content, _ := os.ReadFile(filepath.Join(root, path))
+		return string(content)
+	}
+
+	env := Environment{
+		Codespaces: getenv("CODESPACES") == "true",
+		Systemd:    exists("/run/systemd/system"),
+	}
+
+	switch {
+	case exists("/.dockerenv"):
+		env.ContainerRuntime = "docker"
+	case exists("/run/.containerenv"):
+		env.ContainerRuntime = "podman"
+	case getenv("container") != "":
+		// Set by systemd-nspawn, LXC and podman
+		env.ContainerRuntime = getenv("container")
+	case getenv("KUBERNETES_SERVICE_HOST") != "":
+		env.ContainerRuntime = "kubernetes"
+	default:
+		env.ContainerRuntime = runtimeFromCgroup(read("/proc/1/cgroup"))
+	}
+
+	kernel := strings.ToLower(read("/proc/sys/kernel/osrelease"))
+	env.WSL = getenv("WSL_DISTRO_NAME") != "" || strings.Contains(kernel, "microsoft") || strings.Contains(kernel, "wsl")
+
+	// Devcontainer features are installed during the image build from a
+	// directory that only exists for the build. $_REMOTE_USER and
+	// $_CONTAINER_USER are no signal, they may be kept in the image.
+	featureInstall := exists(FEATURES_INSTALL_DIR)
+	// Image builds run each step as PID 1 through a shell
+	buildStep := strings.HasPrefix(read("/proc/1/cmdline"), "/bin/sh\x00-c\x00")
+	env.ImageBuild = featureInstall || (env.ContainerRuntime != "" && buildStep)
+
+	env.Devcontainer = !env.ImageBuild && (env.Codespaces ||
+		getenv("REMOTE_CONTAINERS") == "true" ||
+		getenv("DEVCONTAINER") == "true" ||
+		getenv("REMOTE_CONTAINERS_IPC") != "")
+
+	if env.Codespaces && env.ContainerRuntime == "" {
+		env.ContainerRuntime = "docker"
+	}
+	return env
+}
+
+// runtimeFromCgroup guesses the container runtime from the cgroup paths of
+// PID 1, empty if they show no container
+func runtimeFromCgroup(cgroup string) string {
+	switch {
+	case strings.Contains(cgroup, "kubepods"):
+		return "kubernetes"
+	case strings.Contains(cgroup, "libpod"):
+		return "podman"
+	case strings.Contains(cgroup, "docker"):
+		return "docker"
+	case strings.Contains(cgroup, "containerd"):
+		return "containerd"
+	case strings.Contains(cgroup, "/lxc"):
+		return "lxc"
+	default:
+		return ""
+	}
+}
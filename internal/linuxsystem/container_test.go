@@ -0,0 +1,101 @@
+package linuxsystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFixture(t *testing.T, root string, path string, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("failed to create fixture directory: %v", err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+}
+
+func envFunc(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestDetectEnvironmentOutsideContainer(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, "/proc/1/cgroup", "0::/init.scope\n")
+	writeFixture(t, root, "/run/systemd/system/.keep", "")
+
+	env := detectEnvironment(root, envFunc(nil))
+	if env.InContainer() || env.ImageBuild || env.Devcontainer || env.WSL {
+		t.Fatalf("expected plain host, got %+v", env)
+	}
+	if !env.Systemd {
+		t.Fatalf("expected systemd to be detected")
+	}
+}
+
+func TestDetectEnvironmentRuntimes(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		want  string
+	}{
+		{"dockerenv", map[string]string{"/.dockerenv": ""}, nil, "docker"},
+		{"containerenv", map[string]string{"/run/.containerenv": ""}, nil, "podman"},
+		{"container variable", nil, map[string]string{"container": "systemd-nspawn"}, "systemd-nspawn"},
+		{"kubernetes", nil, map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}, "kubernetes"},
+		{"cgroup", map[string]string{"/proc/1/cgroup": "12:pids:/docker/0123abcd\n"}, nil, "docker"},
+		{"cgroup containerd", map[string]string{"/proc/1/cgroup": "0::/system.slice/containerd.service\n"}, nil, "containerd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, content := range tt.files {
+				writeFixture(t, root, path, content)
+			}
+			if got := detectEnvironment(root, envFunc(tt.env)).ContainerRuntime; got != tt.want {
+				t.Fatalf("ContainerRuntime = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectEnvironmentImageBuildAndDevcontainer(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, "/.dockerenv", "")
+
+	users := envFunc(map[string]string{"_REMOTE_USER": "vscode", "_CONTAINER_USER": "root"})
+	if detectEnvironment(root, users).ImageBuild {
+		t.Fatalf("expected feature users alone not to indicate an image build")
+	}
+
+	writeFixture(t, root, FEATURES_INSTALL_DIR+"/fzf/install.sh", "")
+	build := detectEnvironment(root, users)
+	if !build.ImageBuild || build.Devcontainer {
+		t.Fatalf("expected feature install to be an image build, got %+v", build)
+	}
+	os.RemoveAll(filepath.Join(root, FEATURES_INSTALL_DIR))
+
+	writeFixture(t, root, "/proc/1/cmdline", "/bin/sh\x00-c\x00apt-get update\x00")
+	if !detectEnvironment(root, envFunc(nil)).ImageBuild {
+		t.Fatalf("expected shell as PID 1 to be an image build")
+	}
+
+	running := t.TempDir()
+	writeFixture(t, running, "/.dockerenv", "")
+	writeFixture(t, running, "/proc/1/cmdline", "/bin/sh\x00/usr/local/share/docker-init.sh\x00")
+	codespaces := detectEnvironment(running, envFunc(map[string]string{"CODESPACES": "true"}))
+	if codespaces.ImageBuild || !codespaces.Devcontainer || !codespaces.Codespaces {
+		t.Fatalf("expected running Codespace, got %+v", codespaces)
+	}
+}
+
+func TestDetectEnvironmentWSL(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, "/proc/sys/kernel/osrelease", "5.15.153.1-microsoft-standard-WSL2\n")
+	if !detectEnvironment(root, envFunc(nil)).WSL {
+		t.Fatalf("expected WSL kernel to be detected")
+	}
+}
@@ -0,0 +1,158 @@
+package linuxsystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"unicode"
+
+	"golang.org/x/sys/unix"
+)
+
+// Fact is a named piece of information about the system. Values are strings,
+// booleans or string slices.
+type Fact struct {
+	Key   string
+	Value any
+}
+
+// PACKAGE_MANAGERS are the package manager binaries reported by Facts, in
+// order of preference
+var PACKAGE_MANAGERS = []string{"apk", "apt-get", "dnf", "microdnf", "yum", "zypper", "pacman"}
+
+// Facts collects the facts describing the running system
+func Facts() []Fact {
+	libc, libcVersion := GetLibc()
+	release, err := GetOSRelease()
+	if err != nil {
+		release = &OSRelease{}
+	}
+	environment := DetectEnvironment()
+	remoteUser, err := ResolveTargetUser()
+	if err != nil {
+		remoteUser = &User{}
+	}
+	architecture := GetArchitecture()
+	aliases := architecture.Aliases()
+	return []Fact{
+		{"IsLinux", IsLinux()},
+		{"Architecture", string(architecture)},
+		{"GoArch", aliases.Go},
+		{"DebianArch", aliases.Debian},
+		{"RustTarget", architecture.RustTriple(libc)},
+		{"DockerPlatform", aliases.Docker},
+		{"Distribution", string(release.Distribution())},
+		{"DistributionID", release.ID},
+		{"DistributionName", release.PrettyName},
+		{"DistributionVersion", release.VersionID},
+		{"DistributionCodename", release.VersionCodename},
+		{"DistributionLike", append([]string{}, release.IDLike...)},
+		{"DistributionFamily", string(release.Family())},
+		{"Libc", string(libc)},
+		{"LibcVersion", libcVersion},
+		{"Kernel", GetKernelRelease()},
+		{"InContainer", environment.InContainer()},
+		{"ContainerRuntime", environment.ContainerRuntime},
+		{"ImageBuild", environment.ImageBuild},
+		{"Devcontainer", environment.Devcontainer},
+		{"Codespaces", environment.Codespaces},
+		{"WSL", environment.WSL},
+		{"Systemd", environment.Systemd},
+		{"RemoteUser", remoteUser.Name},
+		{"RemoteUserHome", remoteUser.Home},
+		{"PackageManagers", GetPackageManagers()},
+		{"HasRootPrivileges", HasRootPrivileges()},
+	}
+}
+
+// GetKernelRelease returns the kernel release, e.g. 6.1.0-18-amd64
+func GetKernelRelease() string {
+	var utsname unix.Utsname
+	if err := unix.Uname(&utsname); err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(utsname.Release[:])
+}
+
+// GetPackageManagers returns the package managers found on PATH
+func GetPackageManagers() []string {
+	managers := []string{}
+	for _, manager := range PACKAGE_MANAGERS {
+		if _, err := exec.LookPath(manager); err == nil {
+			managers = append(managers, manager)
+		}
+	}
+	return managers
+}
+
+// LookupFact returns the fact named key, ignoring case
+func LookupFact(facts []Fact, key string) (Fact, bool) {
+	for _, fact := range facts {
+		if strings.EqualFold(fact.Key, key) {
+			return fact, true
+		}
+	}
+	return Fact{}, false
+}
+
+// FormatValue renders a fact value as plain text, joining lists with commas
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// WriteFacts writes facts to w in the given format:
+//
+//	text   Key=value lines
+//	json   a JSON object
+//	env    NANOLAYER_KEY=value lines for env files
+//	shell  export NANOLAYER_KEY='value' lines for eval
+func WriteFacts(w io.Writer, facts []Fact, format string) error {
+	switch format {
+	case "", "text":
+		for _, fact := range facts {
+			fmt.Fprintf(w, "%s=%s\n", fact.Key, FormatValue(fact.Value))
+		}
+	case "json":
+		object := make(map[string]any, len(facts))
+		for _, fact := range facts {
+			object[fact.Key] = fact.Value
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(object)
+	case "env":
+		for _, fact := range facts {
+			fmt.Fprintf(w, "%s=%s\n", EnvName(fact.Key), FormatValue(fact.Value))
+		}
+	case "shell":
+		for _, fact := range facts {
+			value := strings.ReplaceAll(FormatValue(fact.Value), "'", `'\''`)
+			fmt.Fprintf(w, "export %s='%s'\n", EnvName(fact.Key), value)
+		}
+	default:
+		return fmt.Errorf("unsupported output format %q, expected text, json, env or shell", format)
+	}
+	return nil
+}
+
+// EnvName converts a fact key to its environment variable name, e.g.
+// HasRootPrivileges to NANOLAYER_HAS_ROOT_PRIVILEGES
+func EnvName(key string) string {
+	key = strings.TrimPrefix(key, "Nanolayer")
+	var name strings.Builder
+	name.WriteString("NANOLAYER")
+	for i, r := range key {
+		if i == 0 || unicode.IsUpper(r) {
+			name.WriteByte('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
@@ -0,0 +1,67 @@
+package linuxsystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var testFacts = []Fact{
+	{"IsLinux", true},
+	{"Distribution", "debian"},
+	{"RemoteUser", "it's me"},
+	{"PackageManagers", []string{"apt-get", "dnf"}},
+	{"NanolayerVersion", "1.0.0"},
+}
+
+func TestWriteFactsFormats(t *testing.T) {
+	tests := map[string]string{
+		"text": "IsLinux=true\nDistribution=debian\nRemoteUser=it's me\nPackageManagers=apt-get,dnf\nNanolayerVersion=1.0.0\n",
+		"env":  "NANOLAYER_IS_LINUX=true\nNANOLAYER_DISTRIBUTION=debian\nNANOLAYER_REMOTE_USER=it's me\nNANOLAYER_PACKAGE_MANAGERS=apt-get,dnf\nNANOLAYER_VERSION=1.0.0\n",
+		"shell": "export NANOLAYER_IS_LINUX='true'\nexport NANOLAYER_DISTRIBUTION='debian'\nexport NANOLAYER_REMOTE_USER='it'\\''s me'\n" +
+			"export NANOLAYER_PACKAGE_MANAGERS='apt-get,dnf'\nexport NANOLAYER_VERSION='1.0.0'\n",
+	}
+	for format, want := range tests {
+		var out bytes.Buffer
+		if err := WriteFacts(&out, testFacts, format); err != nil {
+			t.Fatalf("WriteFacts(%s) returned error: %v", format, err)
+		}
+		if out.String() != want {
+			t.Fatalf("WriteFacts(%s) = %q, want %q", format, out.String(), want)
+		}
+	}
+}
+
+func TestWriteFactsJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteFacts(&out, testFacts, "json"); err != nil {
+		t.Fatalf("WriteFacts returned error: %v", err)
+	}
+
+	var decoded struct {
+		IsLinux         bool
+		PackageManagers []string
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if !decoded.IsLinux || len(decoded.PackageManagers) != 2 {
+		t.Fatalf("unexpected decoded facts: %+v", decoded)
+	}
+}
+
+func TestWriteFactsUnsupportedFormat(t *testing.T) {
+	if err := WriteFacts(&bytes.Buffer{}, testFacts, "yaml"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
+
+func TestLookupFactIgnoresCase(t *testing.T) {
+	fact, ok := LookupFact(testFacts, "distribution")
+	if !ok || fact.Value != "debian" {
+		t.Fatalf("expected distribution fact, got %+v (found=%v)", fact, ok)
+	}
+	if _, ok := LookupFact(testFacts, "missing"); ok {
+		t.Fatalf("expected missing fact not to be found")
+	}
+}
@@ -0,0 +1,122 @@
+package linuxsystem
+
+import (
+	"debug/elf"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Libc is the C library flavor of the system
+type Libc string
+
+const (
+	Glibc       Libc = "glibc"
+	Musl        Libc = "musl"
+	UnknownLibc Libc = "unknown"
+)
+
+// LIBC_PROBE_BINARY is the executable whose ELF interpreter reveals the libc
+const LIBC_PROBE_BINARY = "/bin/sh"
+
+// ABI returns the libc as used in target triples and asset names, "gnu" or
+// "musl", or an empty string if unknown
+func (l Libc) ABI() string {
+	switch l {
+	case Glibc:
+		return "gnu"
+	case Musl:
+		return "musl"
+	default:
+		return ""
+	}
+}
+
+// GetLibc returns the C library flavor and its version, if it can be
+// determined. The flavor comes from the dynamic loader that /bin/sh is linked
+// against; the version from the musl loader, getconf or ldd.
+func GetLibc() (Libc, string) {
+	interpreter, err := elfInterpreter(LIBC_PROBE_BINARY)
+	libc := libcFromInterpreter(interpreter)
+	if err != nil || libc == UnknownLibc {
+		// /bin/sh may be a static binary, look for the loaders instead
+		if matches, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+			libc, interpreter = Musl, matches[0]
+		} else if matches, _ := filepath.Glob("/lib*/ld-linux*.so.*"); len(matches) > 0 {
+			libc = Glibc
+		}
+	}
+
+	switch libc {
+	case Musl:
+		// The musl loader prints its version to stderr when run without arguments
+		output, _ := exec.Command(interpreter).CombinedOutput()
+		return Musl, parseMuslVersion(string(output))
+	case Glibc:
+		if output, err := exec.Command("getconf", "GNU_LIBC_VERSION").Output(); err == nil {
+			// Output looks like "glibc 2.36"
+			if fields := strings.Fields(string(output)); len(fields) == 2 {
+				return Glibc, fields[1]
+			}
+		}
+		output, _ := exec.Command("ldd", "--version").Output()
+		return Glibc, parseLddVersion(string(output))
+	}
+	return UnknownLibc, ""
+}
+
+// elfInterpreter returns the program interpreter (PT_INTERP) of an ELF binary
+func elfInterpreter(path string) (string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		data := make([]byte, prog.Filesz)
+		if _, err := prog.ReadAt(data, 0); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\x00"), nil
+	}
+	return "", nil
+}
+
+// libcFromInterpreter maps a dynamic loader path to its libc, e.g.
+// /lib/ld-musl-x86_64.so.1 to musl and /lib64/ld-linux-x86-64.so.2 to glibc
+func libcFromInterpreter(interpreter string) Libc {
+	name := filepath.Base(interpreter)
+	switch {
+	case strings.HasPrefix(name, "ld-musl"):
+		return Musl
+	case strings.HasPrefix(name, "ld-linux"), strings.HasPrefix(name, "ld64.so"), strings.HasPrefix(name, "ld.so"):
+		return Glibc
+	default:
+		return UnknownLibc
+	}
+}
+
+// parseMuslVersion extracts the version from the usage text of the musl loader
+func parseMuslVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "Version "); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// parseLddVersion extracts the version from the first line of ldd --version,
+// e.g. "ldd (Debian GLIBC 2.36-9+deb12u4) 2.36"
+func parseLddVersion(output string) string {
+	line, _, _ := strings.Cut(output, "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
@@ -0,0 +1,39 @@
+package linuxsystem
+
+import "testing"
+
+func TestLibcFromInterpreter(t *testing.T) {
+	tests := map[string]Libc{
+		"/lib/ld-musl-x86_64.so.1":    Musl,
+		"/lib/ld-musl-aarch64.so.1":   Musl,
+		"/lib64/ld-linux-x86-64.so.2": Glibc,
+		"/lib/ld-linux-aarch64.so.1":  Glibc,
+		"/lib/ld-linux-armhf.so.3":    Glibc,
+		"/lib64/ld64.so.2":            Glibc,
+		"":                            UnknownLibc,
+		"/system/bin/linker64":        UnknownLibc,
+	}
+	for interpreter, want := range tests {
+		if got := libcFromInterpreter(interpreter); got != want {
+			t.Fatalf("libcFromInterpreter(%q) = %q, want %q", interpreter, got, want)
+		}
+	}
+}
+
+func TestParseLibcVersions(t *testing.T) {
+	musl := "musl libc (x86_64)\nVersion 1.2.5\nDynamic Program Loader\nUsage: /lib/ld-musl-x86_64.so.1 [options] [--] pathname [args]\n"
+	if got := parseMuslVersion(musl); got != "1.2.5" {
+		t.Fatalf("parseMuslVersion = %q, want 1.2.5", got)
+	}
+
+	ldd := "ldd (Debian GLIBC 2.36-9+deb12u4) 2.36\nCopyright (C) 2022 Free Software Foundation, Inc.\n"
+	if got := parseLddVersion(ldd); got != "2.36" {
+		t.Fatalf("parseLddVersion = %q, want 2.36", got)
+	}
+}
+
+func TestLibcABI(t *testing.T) {
+	if Glibc.ABI() != "gnu" || Musl.ABI() != "musl" || UnknownLibc.ABI() != "" {
+		t.Fatalf("unexpected ABI names: %q %q %q", Glibc.ABI(), Musl.ABI(), UnknownLibc.ABI())
+	}
+}
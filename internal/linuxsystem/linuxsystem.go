@@ -1,10 +1,6 @@
 package linuxsystem
 
 import (
-	"bufio"
-	"os"
-	"strings"
-
 	"golang.org/x/sys/unix"
 )
 
@@ -26,6 +22,12 @@ const (
 	PPC64  Architecture = "ppc64"
 	S390   Architecture = "s390"
 	OTHER  Architecture = "other"
+
+	PPC64LE     Architecture = "ppc64le"
+	RISCV64     Architecture = "riscv64"
+	LOONGARCH64 Architecture = "loongarch64"
+	MIPS64      Architecture = "mips64"
+	MIPS64LE    Architecture = "mips64le"
 )
 
 // LinuxReleaseID represents the Linux distribution
@@ -41,6 +43,12 @@ const (
 	Raspbian LinuxReleaseID = "raspbian"
 	Manjaro  LinuxReleaseID = "manjaro"
 	Arch     LinuxReleaseID = "arch"
+	PopOS    LinuxReleaseID = "pop"
+	Mint     LinuxReleaseID = "linuxmint"
+	Rocky    LinuxReleaseID = "rocky"
+	Alma     LinuxReleaseID = "almalinux"
+	Amazon   LinuxReleaseID = "amzn"
+	Wolfi    LinuxReleaseID = "wolfi"
 	Unknown  LinuxReleaseID = "unknown"
 )
 
@@ -59,86 +67,15 @@ func GetArchitecture() Architecture {
 		machine = append(machine, byte(b))
 	}
 
-	arch := string(machine)
-
-	// Map the architecture string to the enum
-	switch arch {
-	case "arm64", "aarch64":
-		return ARM64
-	case "x86_64", "amd64":
-		return X86_64
-	case "armv5":
-		return ARMV5
-	case "armv6":
-		return ARMV6
-	case "armv7", "armv7l":
-		return ARMV7
-	case "armhf":
-		return ARMHF
-	case "arm32":
-		return ARM32
-	case "i386":
-		return I386
-	case "i686":
-		return I686
-	case "ppc64", "ppc64le":
-		return PPC64
-	case "s390", "s390x":
-		return S390
-	default:
-		return OTHER
-	}
+	return ArchitectureFromMachine(string(machine))
 }
 
 func GetDistribution() LinuxReleaseID {
-	file, err := os.Open(OS_RELEASE_FILE)
+	release, err := GetOSRelease()
 	if err != nil {
 		return Unknown
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Look for ID= line
-		if strings.HasPrefix(line, "ID=") {
-			// Remove ID= prefix and quotes
-			id := strings.TrimPrefix(line, "ID=")
-			id = strings.Trim(id, "\"'")
-			id = strings.ToLower(id)
-
-			// Map to enum
-			switch id {
-			case "ubuntu":
-				return Ubuntu
-			case "debian":
-				return Debian
-			case "alpine":
-				return Alpine
-			case "rhel":
-				return RHEL
-			case "fedora":
-				return Fedora
-			case "opensuse", "opensuse-leap", "opensuse-tumbleweed":
-				return OpenSUSE
-			case "raspbian":
-				return Raspbian
-			case "manjaro":
-				return Manjaro
-			case "arch":
-				return Arch
-			default:
-				return Unknown
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return Unknown
-	}
-
-	return Unknown
+	return release.Distribution()
 }
 
 func IsLinux() bool {
@@ -157,7 +94,3 @@ func IsLinux() bool {
 	}
 	return string(sysname) == "Linux"
 }
-
-func HasRootPrivileges() bool {
-	return os.Geteuid() == 0 || os.Getenv("SUDO_UID") != ""
-}
@@ -27,21 +27,38 @@ func TestIsLinuxMatchesGOOS(t *testing.T) {
 }
 
 func TestHasRootPrivileges(t *testing.T) {
-	t.Setenv("SUDO_UID", "")
+	// SUDO_UID is inherited by child processes and grants nothing by itself
+	t.Setenv("SUDO_UID", "1000")
 
 	if os.Geteuid() == 0 {
 		if !HasRootPrivileges() {
 			t.Fatalf("expected HasRootPrivileges() to be true when running as root")
 		}
-	} else {
-		if HasRootPrivileges() {
-			t.Fatalf("expected HasRootPrivileges() to be false without root privileges")
-		}
+		return
+	}
 
-		t.Setenv("SUDO_UID", "1000")
-		if !HasRootPrivileges() {
-			t.Fatalf("expected HasRootPrivileges() to be true when SUDO_UID is set")
-		}
+	status, err := os.ReadFile(PROC_STATUS_FILE)
+	if err != nil {
+		t.Skipf("cannot read %s: %v", PROC_STATUS_FILE, err)
+	}
+	capabilities, _ := effectiveCapabilities(string(status))
+	want := hasCapabilities(capabilities, rootCapabilities...)
+	if got := HasRootPrivileges(); got != want {
+		t.Fatalf("HasRootPrivileges() = %v, want %v for CapEff %x", got, want, capabilities)
+	}
+}
+
+func TestEffectiveCapabilities(t *testing.T) {
+	status := "Name:\tnanolayer\nCapInh:\t0000000000000000\nCapEff:\t00000000a80425fb\nCapBnd:\t00000000a80425fb\n"
+	capabilities, ok := effectiveCapabilities(status)
+	if !ok || capabilities != 0xa80425fb {
+		t.Fatalf("effectiveCapabilities = %x (%v), want a80425fb", capabilities, ok)
+	}
+	if !hasCapabilities(capabilities, rootCapabilities...) {
+		t.Fatalf("expected default container capabilities to include %v", rootCapabilities)
+	}
+	if hasCapabilities(0, CAP_CHOWN) {
+		t.Fatalf("expected empty set not to include CAP_CHOWN")
 	}
 }
 
@@ -82,7 +99,7 @@ func expectedArchitectureFromUname(t *testing.T) Architecture {
 		return X86_64
 	case "armv5":
 		return ARMV5
-	case "armv6":
+	case "armv6", "armv6l":
 		return ARMV6
 	case "armv7", "armv7l":
 		return ARMV7
@@ -94,10 +111,16 @@ func expectedArchitectureFromUname(t *testing.T) Architecture {
 		return I386
 	case "i686":
 		return I686
-	case "ppc64", "ppc64le":
+	case "ppc64":
 		return PPC64
+	case "ppc64le":
+		return PPC64LE
 	case "s390", "s390x":
 		return S390
+	case "riscv64":
+		return RISCV64
+	case "loongarch64":
+		return LOONGARCH64
 	default:
 		return OTHER
 	}
@@ -140,6 +163,18 @@ func distributionFromOSRelease() (LinuxReleaseID, error) {
 			return Manjaro, nil
 		case "arch":
 			return Arch, nil
+		case "pop":
+			return PopOS, nil
+		case "linuxmint":
+			return Mint, nil
+		case "rocky":
+			return Rocky, nil
+		case "almalinux":
+			return Alma, nil
+		case "amzn":
+			return Amazon, nil
+		case "wolfi":
+			return Wolfi, nil
 		default:
 			return Unknown, nil
 		}
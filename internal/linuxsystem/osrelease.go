@@ -0,0 +1,167 @@
+package linuxsystem
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OS_RELEASE_FALLBACK_FILE is read when OS_RELEASE_FILE does not exist
+const OS_RELEASE_FALLBACK_FILE = "/usr/lib/os-release"
+
+// LSB_RELEASE_FILE is read on systems without os-release
+const LSB_RELEASE_FILE = "/etc/lsb-release"
+
+// Family groups distributions sharing a package ecosystem
+type Family string
+
+const (
+	DebianFamily  Family = "debian"
+	RHELFamily    Family = "rhel"
+	SUSEFamily    Family = "suse"
+	ArchFamily    Family = "arch"
+	AlpineFamily  Family = "alpine"
+	UnknownFamily Family = "unknown"
+)
+
+// OSRelease holds the identification fields of os-release(5)
+type OSRelease struct {
+	ID              string
+	IDLike          []string
+	VersionID       string
+	VersionCodename string
+	PrettyName      string
+}
+
+// distributions maps os-release IDs to the known distributions
+var distributions = map[string]LinuxReleaseID{
+	"ubuntu":              Ubuntu,
+	"debian":              Debian,
+	"alpine":              Alpine,
+	"rhel":                RHEL,
+	"fedora":              Fedora,
+	"opensuse":            OpenSUSE,
+	"opensuse-leap":       OpenSUSE,
+	"opensuse-tumbleweed": OpenSUSE,
+	"raspbian":            Raspbian,
+	"manjaro":             Manjaro,
+	"arch":                Arch,
+	"pop":                 PopOS,
+	"linuxmint":           Mint,
+	"rocky":               Rocky,
+	"almalinux":           Alma,
+	"amzn":                Amazon,
+	"wolfi":               Wolfi,
+}
+
+// families maps os-release IDs, including ID_LIKE values, to their family
+var families = map[string]Family{
+	"debian":    DebianFamily,
+	"ubuntu":    DebianFamily,
+	"raspbian":  DebianFamily,
+	"rhel":      RHELFamily,
+	"fedora":    RHELFamily,
+	"centos":    RHELFamily,
+	"rocky":     RHELFamily,
+	"almalinux": RHELFamily,
+	"amzn":      RHELFamily,
+	"suse":      SUSEFamily,
+	"opensuse":  SUSEFamily,
+	"sles":      SUSEFamily,
+	"arch":      ArchFamily,
+	"manjaro":   ArchFamily,
+	"alpine":    AlpineFamily,
+	"wolfi":     AlpineFamily,
+}
+
+// GetOSRelease reads the os-release information of the running system
+func GetOSRelease() (*OSRelease, error) {
+	return ReadOSRelease("/")
+}
+
+// ReadOSRelease reads the os-release information of the system rooted at
+// root, falling back to /usr/lib/os-release and then /etc/lsb-release
+func ReadOSRelease(root string) (*OSRelease, error) {
+	for _, path := range []string{OS_RELEASE_FILE, OS_RELEASE_FALLBACK_FILE} {
+		values, err := readKeyValueFile(filepath.Join(root, path))
+		if err == nil {
+			return &OSRelease{
+				ID:              strings.ToLower(values["ID"]),
+				IDLike:          strings.Fields(strings.ToLower(values["ID_LIKE"])),
+				VersionID:       values["VERSION_ID"],
+				VersionCodename: values["VERSION_CODENAME"],
+				PrettyName:      values["PRETTY_NAME"],
+			}, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	values, err := readKeyValueFile(filepath.Join(root, LSB_RELEASE_FILE))
+	if err != nil {
+		return nil, fmt.Errorf("no os-release or lsb-release file found: %w", err)
+	}
+	return &OSRelease{
+		ID:              strings.ToLower(values["DISTRIB_ID"]),
+		VersionID:       values["DISTRIB_RELEASE"],
+		VersionCodename: values["DISTRIB_CODENAME"],
+		PrettyName:      values["DISTRIB_DESCRIPTION"],
+	}, nil
+}
+
+// Distribution returns the known distribution of r, or Unknown
+func (r *OSRelease) Distribution() LinuxReleaseID {
+	if id, ok := distributions[r.ID]; ok {
+		return id
+	}
+	return Unknown
+}
+
+// Family resolves the family of r from its ID, then from ID_LIKE in order
+func (r *OSRelease) Family() Family {
+	for _, id := range append([]string{r.ID}, r.IDLike...) {
+		if family, ok := families[id]; ok {
+			return family
+		}
+	}
+	return UnknownFamily
+}
+
+// readKeyValueFile parses the KEY=value lines of an os-release style file
+func readKeyValueFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[key] = unquote(value)
+	}
+	return values, scanner.Err()
+}
+
+// unquote strips shell quoting from an os-release value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		quote := value[0]
+		value = value[1 : len(value)-1]
+		if quote == '"' {
+			value = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\$`, `$`, "\\`", "`").Replace(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,63 @@
+package linuxsystem
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadOSReleaseFixtures(t *testing.T) {
+	tests := []struct {
+		root         string
+		distribution LinuxReleaseID
+		family       Family
+		versionID    string
+		codename     string
+		prettyName   string
+	}{
+		{"ubuntu", Ubuntu, DebianFamily, "24.04", "noble", "Ubuntu 24.04.1 LTS"},
+		{"pop", PopOS, DebianFamily, "22.04", "jammy", "Pop!_OS 22.04 LTS"},
+		{"linuxmint", Mint, DebianFamily, "21.3", "virginia", "Linux Mint 21.3"},
+		{"rocky", Rocky, RHELFamily, "9.4", "", "Rocky Linux 9.4 (Blue Onyx)"},
+		{"almalinux", Alma, RHELFamily, "9.4", "", "AlmaLinux 9.4 (Seafoam Ocelot)"},
+		{"amzn", Amazon, RHELFamily, "2023", "", "Amazon Linux 2023.5.20240805"},
+		{"wolfi", Wolfi, AlpineFamily, "20230201", "", "Wolfi"},
+		{"debian-usrlib", Debian, DebianFamily, "12", "bookworm", "Debian GNU/Linux 12 (bookworm)"},
+		{"lsb", Ubuntu, DebianFamily, "20.04", "focal", "Ubuntu 20.04.6 LTS"},
+		{"derivative", Unknown, SUSEFamily, "1", "", `MyCorp "Enterprise" Linux`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.root, func(t *testing.T) {
+			release, err := ReadOSRelease(filepath.Join("testdata", tt.root))
+			if err != nil {
+				t.Fatalf("ReadOSRelease returned error: %v", err)
+			}
+			if got := release.Distribution(); got != tt.distribution {
+				t.Fatalf("Distribution() = %q, want %q", got, tt.distribution)
+			}
+			if got := release.Family(); got != tt.family {
+				t.Fatalf("Family() = %q, want %q", got, tt.family)
+			}
+			if release.VersionID != tt.versionID || release.VersionCodename != tt.codename || release.PrettyName != tt.prettyName {
+				t.Fatalf("unexpected release fields: %+v", release)
+			}
+		})
+	}
+}
+
+func TestReadOSReleaseIDLike(t *testing.T) {
+	release, err := ReadOSRelease(filepath.Join("testdata", "rocky"))
+	if err != nil {
+		t.Fatalf("ReadOSRelease returned error: %v", err)
+	}
+	if want := []string{"rhel", "centos", "fedora"}; !reflect.DeepEqual(release.IDLike, want) {
+		t.Fatalf("IDLike = %v, want %v", release.IDLike, want)
+	}
+}
+
+func TestReadOSReleaseMissing(t *testing.T) {
+	if _, err := ReadOSRelease(t.TempDir()); err == nil {
+		t.Fatalf("expected error when no release file exists")
+	}
+}
@@ -0,0 +1,55 @@
+package linuxsystem
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PROC_STATUS_FILE holds the capability sets of the running process
+const PROC_STATUS_FILE = "/proc/self/status"
+
+// Linux capability numbers, see capabilities(7)
+const (
+	CAP_CHOWN        = 0
+	CAP_DAC_OVERRIDE = 1
+	CAP_FOWNER       = 3
+)
+
+// rootCapabilities are needed to write and own files anywhere, which is what
+// installers rely on root for
+var rootCapabilities = []int{CAP_CHOWN, CAP_DAC_OVERRIDE, CAP_FOWNER}
+
+// HasRootPrivileges reports whether the process runs with an effective uid
+// of 0 or holds the capabilities to write and own files anywhere
+func HasRootPrivileges() bool {
+	if os.Geteuid() == 0 {
+		return true
+	}
+	status, err := os.ReadFile(PROC_STATUS_FILE)
+	if err != nil {
+		return false
+	}
+	capabilities, ok := effectiveCapabilities(string(status))
+	return ok && hasCapabilities(capabilities, rootCapabilities...)
+}
+
+// effectiveCapabilities parses the CapEff bitmask of a /proc/<pid>/status file
+func effectiveCapabilities(status string) (uint64, bool) {
+	for _, line := range strings.Split(status, "\n") {
+		if value, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			capabilities, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+			return capabilities, err == nil
+		}
+	}
+	return 0, false
+}
+
+func hasCapabilities(set uint64, capabilities ...int) bool {
+	for _, capability := range capabilities {
+		if set&(1<<capability) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package linuxsystem
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PASSWD_FILE is the user database read by LookupUser
+const PASSWD_FILE = "/etc/passwd"
+
+// User is an account from the user database
+type User struct {
+	Name  string
+	UID   int
+	GID   int
+	Home  string
+	Shell string
+}
+
+// LookupUser returns the user named name, or with uid name if it is numeric
+func LookupUser(name string) (*User, error) {
+	return lookupUser("/", name)
+}
+
+// ResolveTargetUser returns the user tools are installed for: the first of
+// $_REMOTE_USER, $_CONTAINER_USER and $SUDO_USER that is set, otherwise the
+// current user
+func ResolveTargetUser() (*User, error) {
+	return resolveTargetUser("/", os.Getenv, os.Geteuid())
+}
+
+// GetRemoteUser returns the name of the user tools are installed for
+func GetRemoteUser() string {
+	user, err := ResolveTargetUser()
+	if err != nil {
+		return ""
+	}
+	return user.Name
+}
+
+func resolveTargetUser(root string, getenv func(string) string, euid int) (*User, error) {
+	for _, env := range []string{"_REMOTE_USER", "_CONTAINER_USER", "SUDO_USER"} {
+		if name := getenv(env); name != "" {
+			user, err := lookupUser(root, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", env, err)
+			}
+			return user, nil
+		}
+	}
+
+	user, err := lookupUser(root, strconv.Itoa(euid))
+	if err == nil {
+		return user, nil
+	}
+	// Containers may run with a uid that has no passwd entry
+	home := getenv("HOME")
+	if home == "" {
+		return nil, err
+	}
+	return &User{Name: getenv("USER"), UID: euid, GID: euid, Home: home}, nil
+}
+
+func lookupUser(root string, name string) (*User, error) {
+	file, err := os.Open(filepath.Join(root, PASSWD_FILE))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user database: %w", err)
+	}
+	defer file.Close()
+
+	_, numericErr := strconv.Atoi(name)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		user, ok := parsePasswdLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if user.Name == name || (numericErr == nil && strconv.Itoa(user.UID) == name) {
+			return user, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read user database: %w", err)
+	}
+	return nil, fmt.Errorf("unknown user %q", name)
+}
+
+// parsePasswdLine parses a name:password:uid:gid:gecos:home:shell entry
+func parsePasswdLine(line string) (*User, bool) {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+	fields := strings.Split(line, ":")
+	if len(fields) != 7 {
+		return nil, false
+	}
+	uid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, false
+	}
+	gid, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, false
+	}
+	return &User{Name: fields[0], UID: uid, GID: gid, Home: fields[5], Shell: fields[6]}, true
+}
@@ -0,0 +1,58 @@
+package linuxsystem
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+var usersRoot = filepath.Join("testdata", "users")
+
+func TestLookupUser(t *testing.T) {
+	user, err := lookupUser(usersRoot, "vscode")
+	if err != nil {
+		t.Fatalf("lookupUser returned error: %v", err)
+	}
+	want := User{Name: "vscode", UID: 1000, GID: 1000, Home: "/home/vscode", Shell: "/bin/zsh"}
+	if *user != want {
+		t.Fatalf("lookupUser = %+v, want %+v", *user, want)
+	}
+
+	byID, err := lookupUser(usersRoot, "1001")
+	if err != nil || byID.Name != "node" || byID.GID != 1002 {
+		t.Fatalf("expected lookup by uid to find node, got %+v (%v)", byID, err)
+	}
+
+	if _, err := lookupUser(usersRoot, "missing"); err == nil {
+		t.Fatalf("expected error for unknown user")
+	}
+}
+
+func TestResolveTargetUser(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		euid int
+		want string
+	}{
+		{"remote user", map[string]string{"_REMOTE_USER": "vscode", "SUDO_USER": "node"}, 0, "vscode"},
+		{"container user", map[string]string{"_CONTAINER_USER": "node"}, 0, "node"},
+		{"sudo user", map[string]string{"SUDO_USER": "node"}, 0, "node"},
+		{"current user", nil, 1000, "vscode"},
+		{"unknown uid", map[string]string{"HOME": "/tmp/home", "USER": "ghost"}, 4242, "ghost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := resolveTargetUser(usersRoot, envFunc(tt.env), tt.euid)
+			if err != nil {
+				t.Fatalf("resolveTargetUser returned error: %v", err)
+			}
+			if user.Name != tt.want {
+				t.Fatalf("resolveTargetUser = %q, want %q", user.Name, tt.want)
+			}
+		})
+	}
+
+	if _, err := resolveTargetUser(usersRoot, envFunc(map[string]string{"_REMOTE_USER": "missing"}), 0); err == nil {
+		t.Fatalf("expected error for unknown remote user")
+	}
+}
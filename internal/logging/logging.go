@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Events name the phase a log record belongs to, in its "event" attribute
+const (
+	EventResolve  = "resolve"
+	EventDownload = "download"
+	EventExtract  = "extract"
+	EventInstall  = "install"
+	EventVerify   = "verify"
+)
+
+// EVENT_KEY is the attribute holding the event of a record
+const EVENT_KEY = "event"
+
+// Formats supported by Configure
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var (
+	mu     sync.Mutex
+	level  = new(slog.LevelVar)
+	format = FormatText
+)
+
+// Configure sets the format and level of every logger subsequently created
+// by New, and of the default slog logger: info by default, debug when
+// verbose and warnings only when quiet
+func Configure(logFormat string, verbose bool, quiet bool) error {
+	if logFormat != FormatText && logFormat != FormatJSON {
+		return fmt.Errorf("unsupported log format %q, expected text or json", logFormat)
+	}
+
+	mu.Lock()
+	format = logFormat
+	mu.Unlock()
+	switch {
+	case verbose:
+		level.Set(slog.LevelDebug)
+	case quiet:
+		level.Set(slog.LevelWarn)
+	default:
+		level.Set(slog.LevelInfo)
+	}
+	slog.SetDefault(New(os.Stderr))
+	return nil
+}
+
+// New returns a logger writing to w, os.Stderr if nil, in the configured
+// format and level. Callers that buffer output per task, such as parallel
+// installs, pass their buffer so records stay in task order.
+func New(w io.Writer) *slog.Logger {
+	if w == nil {
+		w = os.Stderr
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+	// Timestamps only clutter interactive output
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: dropTime}))
+}
+
+func dropTime(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return attr
+}
+
+// Event returns logger tagged with event
+func Event(logger *slog.Logger, event string) *slog.Logger {
+	return logger.With(EVENT_KEY, event)
+}
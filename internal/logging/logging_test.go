@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func configure(t *testing.T, format string, verbose bool, quiet bool) {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		Configure(FormatText, false, false)
+		slog.SetDefault(previous)
+	})
+	if err := Configure(format, verbose, quiet); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+}
+
+func TestJSONRecordsCarryEvent(t *testing.T) {
+	configure(t, FormatJSON, false, false)
+
+	var out bytes.Buffer
+	Event(New(&out), EventDownload).Info("Downloaded asset", "url", "https://example.com/tool.tar.gz")
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", out.String(), err)
+	}
+	if record["event"] != EventDownload || record["msg"] != "Downloaded asset" || record["level"] != "INFO" {
+		t.Fatalf("unexpected record: %v", record)
+	}
+	if _, ok := record["time"]; !ok {
+		t.Fatalf("expected JSON records to keep the time, got %v", record)
+	}
+}
+
+func TestTextRecordsOmitTime(t *testing.T) {
+	configure(t, FormatText, false, false)
+
+	var out bytes.Buffer
+	Event(New(&out), EventInstall).Info("Installed file", "destination", "/usr/local/bin/tool")
+	want := "level=INFO msg=\"Installed file\" event=install destination=/usr/local/bin/tool\n"
+	if out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		verbose, quiet bool
+		want           []string
+	}{
+		{false, false, []string{"info", "warn"}},
+		{true, false, []string{"debug", "info", "warn"}},
+		{false, true, []string{"warn"}},
+	}
+	for _, test := range tests {
+		configure(t, FormatText, test.verbose, test.quiet)
+		var out bytes.Buffer
+		logger := New(&out)
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		for _, msg := range []string{"debug", "info", "warn"} {
+			logged := strings.Contains(out.String(), "msg="+msg)
+			expected := strings.Contains(strings.Join(test.want, " "), msg)
+			if logged != expected {
+				t.Fatalf("verbose=%v quiet=%v: logged %s = %v, want %v", test.verbose, test.quiet, msg, logged, expected)
+			}
+		}
+	}
+}
+
+func TestConfigureRejectsUnknownFormat(t *testing.T) {
+	if err := Configure("xml", false, false); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,287 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/apk"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+	"github.com/devcontainer-community/nanolayer-go/internal/parallel"
+	"github.com/devcontainer-community/nanolayer-go/internal/plan"
+)
+
+// Result is the outcome of installing a single manifest item
+type Result struct {
+	Kind     string
+	Name     string
+	Version  string
+	Duration time.Duration
+	Err      error
+}
+
+// Failed reports whether any result carries an error
+func Failed(results []Result) bool {
+	return Err(results) != nil
+}
+
+// Err returns the error of the first failed item, preferring real failures
+// over items skipped after them
+func Err(results []Result) error {
+	var skipped error
+	for _, result := range results {
+		switch {
+		case result.Err == nil:
+		case result.Err == parallel.ErrSkipped:
+			if skipped == nil {
+				skipped = fmt.Errorf("%s: %w", result.Name, result.Err)
+			}
+		default:
+			return fmt.Errorf("%s: %w", result.Name, result.Err)
+		}
+	}
+	return skipped
+}
+
+// InstallOptions converts a tool to options for the GitHub installer on the
+// given architecture
+func (t Tool) InstallOptions(architecture linuxsystem.Architecture) github.InstallOptions {
+	assetUrlTemplate := t.AssetUrlTemplate
+	if assetUrlTemplate == "" {
+		assetUrlTemplate = github.DefaultAssetUrlTemplate
+	}
+	// Without destinations the installer picks the file named after the
+	// asset, or else the only executable
+	destinations := t.Destinations
+	replacements := t.ArchitectureReplacements
+	if replacements == nil {
+		replacements = map[string]string{}
+	}
+
+	return github.InstallOptions{
+		Repo:                     t.Repo,
+		Version:                  t.Version,
+		AssetName:                t.AssetName,
+		AssetUrlTemplate:         assetUrlTemplate,
+		ArchitectureReplacements: replacements,
+		FileDestinations:         destinations,
+		Checksum:                 t.checksumFor(architecture),
+		Libc:                     t.Libc,
+		TargetArchitecture:       architecture,
+		VerifyCommand:            t.VerifyCommand,
+		ExpectVersion:            t.ExpectVersion,
+	}
+}
+
+// checksumFor returns the checksum declared for architecture, looked up by
+// its detected name and by its replacement
+func (t Tool) checksumFor(architecture linuxsystem.Architecture) string {
+	if checksum, ok := t.Checksums[string(architecture)]; ok {
+		return checksum
+	}
+	if replacement, ok := t.ArchitectureReplacements[string(architecture)]; ok {
+		return t.Checksums[replacement]
+	}
+	return ""
+}
+
+// ApplyOptions configures Apply
+type ApplyOptions struct {
+	// Lock pins tools to exact assets when non-nil
+	Lock *Lockfile
+	// Jobs is the number of tools installed concurrently
+	Jobs int
+	// FailFast skips the remaining tools after the first failure
+	FailFast bool
+	// Output receives the installer output of each item, in manifest order, os.Stderr if nil
+	Output io.Writer
+	// Architecture installs tools for another architecture than the detected one
+	Architecture linuxsystem.Architecture
+	// Target rebases tool destinations for a user, see installers.Target
+	Target *installers.Target
+}
+
+func (opts ApplyOptions) architecture() linuxsystem.Architecture {
+	if opts.Architecture != "" {
+		return opts.Architecture
+	}
+	return linuxsystem.GetArchitecture()
+}
+
+// Apply installs every package and tool of m. Packages are installed first,
+// one package manager at a time, so tools can rely on them. Tools are then
+// resolved, downloaded and installed on up to opts.Jobs workers.
+func Apply(ctx context.Context, m *Manifest, opts ApplyOptions) []Result {
+	var results []Result
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	managers := make([]string, 0, len(m.Packages))
+	for manager := range m.Packages {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+	failed := false
+	for _, manager := range managers {
+		packages := m.Packages[manager]
+		start := time.Now()
+		var err error
+		switch {
+		case opts.FailFast && failed:
+			err = parallel.ErrSkipped
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		case manager == "apk":
+			err = apk.InstallPackage(ctx, packages)
+		default:
+			err = fmt.Errorf("unsupported package manager %q", manager)
+		}
+		failed = failed || err != nil
+		for _, pkg := range packages {
+			results = append(results, Result{Kind: manager, Name: pkg, Duration: time.Since(start), Err: err})
+		}
+	}
+
+	architecture := opts.architecture()
+	toolResults := make([]Result, len(m.Tools))
+	tasks := make([]parallel.Task, len(m.Tools))
+	for i, tool := range m.Tools {
+		tasks[i] = func(taskOut io.Writer) error {
+			logging.Event(logging.New(taskOut), logging.EventInstall).Info("Installing tool", "tool", tool.Name, "repo", tool.Repo)
+			start := time.Now()
+			installOpts := tool.InstallOptions(architecture)
+			var err error
+			if opts.Lock != nil {
+				installOpts, err = opts.Lock.LockedInstallOptions(tool, architecture)
+			}
+			installOpts.Output = taskOut
+			installOpts.Target = opts.Target
+			if opts.Jobs > 1 {
+				installOpts.Downloader = download.Default.WithProgressOutput(taskOut)
+			}
+			version := installOpts.Version
+			if err == nil {
+				var installed string
+				installed, err = github.Install(ctx, installOpts)
+				if installed != "" {
+					version = installed
+				}
+			}
+			toolResults[i] = Result{
+				Kind:     tool.Source,
+				Name:     tool.Name,
+				Version:  version,
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			return err
+		}
+	}
+
+	if opts.FailFast && failed {
+		for i, tool := range m.Tools {
+			toolResults[i] = Result{Kind: tool.Source, Name: tool.Name, Version: tool.Version, Err: parallel.ErrSkipped}
+		}
+		return append(results, toolResults...)
+	}
+
+	errs := parallel.Run(ctx, tasks, parallel.Options{Jobs: opts.Jobs, FailFast: opts.FailFast, Output: out})
+	for i, err := range errs {
+		if err == parallel.ErrSkipped {
+			toolResults[i] = Result{Kind: m.Tools[i].Source, Name: m.Tools[i].Name, Version: m.Tools[i].Version, Err: err}
+		}
+	}
+	return append(results, toolResults...)
+}
+
+// Plan resolves every package and tool of m like Apply, without installing
+// anything, and returns what Apply would do. Items that cannot be resolved are
+// recorded in the plan with their error.
+func Plan(ctx context.Context, m *Manifest, opts ApplyOptions) *plan.Plan {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	result := &plan.Plan{}
+
+	managers := make([]string, 0, len(m.Packages))
+	for manager := range m.Packages {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+	for _, manager := range managers {
+		var step plan.Step
+		var err error
+		switch manager {
+		case "apk":
+			step, err = apk.Plan(ctx, m.Packages[manager])
+		default:
+			step = plan.Step{Installer: manager}
+			err = fmt.Errorf("unsupported package manager %q", manager)
+		}
+		if err != nil {
+			step.Fail(err)
+		}
+		result.Add(step)
+	}
+
+	architecture := opts.architecture()
+	steps := make([]plan.Step, len(m.Tools))
+	tasks := make([]parallel.Task, len(m.Tools))
+	for i, tool := range m.Tools {
+		tasks[i] = func(taskOut io.Writer) error {
+			logging.Event(logging.New(taskOut), logging.EventResolve).Info("Resolving tool", "tool", tool.Name, "repo", tool.Repo)
+			installOpts := tool.InstallOptions(architecture)
+			var err error
+			if opts.Lock != nil {
+				installOpts, err = opts.Lock.LockedInstallOptions(tool, architecture)
+			}
+			installOpts.Output = taskOut
+			installOpts.Target = opts.Target
+			if opts.Jobs > 1 {
+				installOpts.Downloader = download.Default.WithProgressOutput(taskOut)
+			}
+			step := plan.Step{Installer: tool.Source, Name: tool.Name, Version: installOpts.Version}
+			if err == nil {
+				step, err = github.Plan(ctx, installOpts)
+				step.Name = tool.Name
+			}
+			if err != nil {
+				step.Fail(err)
+			}
+			steps[i] = step
+			return err
+		}
+	}
+	parallel.Run(ctx, tasks, parallel.Options{Jobs: opts.Jobs, Output: out})
+	result.Add(steps...)
+	return result
+}
+
+// WriteSummary writes a table of results to w
+func WriteSummary(w io.Writer, results []Result) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "KIND\tNAME\tVERSION\tSTATUS\tDURATION")
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		}
+		version := result.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", result.Kind, result.Name, version, status, result.Duration.Round(time.Millisecond))
+	}
+	table.Flush()
+}
@@ -0,0 +1,221 @@
+package manifest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/parallel"
+)
+
+func TestApplyInstallsToolsConcurrentlyInOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(filepath.Base(r.URL.Path), ".tar.gz")
+		if name == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(tarGz(t, "bin/"+name, "payload "+name))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	data := `
+version: 1
+tools:
+  - repo: dev/alpha
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+    destinations:
+      "bin/alpha": ` + filepath.Join(dir, "alpha") + `
+  - repo: dev/missing
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+  - repo: dev/beta
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+    destinations:
+      "bin/beta": ` + filepath.Join(dir, "beta") + `
+`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	results := Apply(context.Background(), m, ApplyOptions{Jobs: 3, Output: &out})
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("expected alpha and beta to succeed, got %v and %v", results[0].Err, results[2].Err)
+	}
+	if results[1].Err == nil {
+		t.Fatalf("expected missing tool to fail")
+	}
+	if !Failed(results) || Err(results) == nil || !strings.HasPrefix(Err(results).Error(), "missing: ") {
+		t.Fatalf("expected Failed and Err to report the failure, got %v", Err(results))
+	}
+
+	for _, name := range []string{"alpha", "beta"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(content) != "payload "+name {
+			t.Fatalf("unexpected installed %s: %q (%v)", name, string(content), err)
+		}
+	}
+
+	output := out.String()
+	alpha := strings.Index(output, "tool=alpha")
+	missing := strings.Index(output, "tool=missing")
+	beta := strings.Index(output, "tool=beta")
+	if alpha < 0 || missing < alpha || beta < missing {
+		t.Fatalf("expected output in manifest order, got:\n%s", output)
+	}
+}
+
+func TestApplyFailFastSkipsTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	data := `
+version: 1
+tools:
+  - repo: dev/first
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+  - repo: dev/second
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	results := Apply(context.Background(), m, ApplyOptions{Jobs: 1, FailFast: true, Output: &bytes.Buffer{}})
+	if results[0].Err == nil || !errors.Is(results[1].Err, parallel.ErrSkipped) {
+		t.Fatalf("expected first to fail and second to be skipped, got %v and %v", results[0].Err, results[1].Err)
+	}
+}
+
+func TestPlanResolvesToolsWithoutInstalling(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tarGz(t, "bin/alpha", "payload"))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "alpha")
+	data := `
+version: 1
+tools:
+  - repo: dev/alpha
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+    destinations:
+      "bin/alpha": ` + dest + `
+`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	p := Plan(context.Background(), m, ApplyOptions{Jobs: 1, Output: &bytes.Buffer{}})
+	if p.Failed() || len(p.Steps) != 1 {
+		t.Fatalf("unexpected plan: %+v", p)
+	}
+	step := p.Steps[0]
+	if step.Name != "alpha" || step.URL != server.URL+"/alpha.tar.gz" || len(step.Writes) != 1 || step.Writes[0] != dest {
+		t.Fatalf("unexpected plan step: %+v", step)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("expected %s not to be written, got %v", dest, err)
+	}
+}
+
+func TestApplyAndPlanReportResolvedVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tarGz(t, "bin/alpha", "payload"))
+	}))
+	defer server.Close()
+	previous := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host != "api.github.com" {
+			return previous.RoundTrip(req)
+		}
+		body := `[{"tag_name":"v2.0.0","prerelease":false},{"tag_name":"v1.4.0","prerelease":false},{"tag_name":"v1.3.0","prerelease":false}]`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+	t.Cleanup(func() { http.DefaultTransport = previous })
+
+	dir := t.TempDir()
+	data := `
+version: 1
+tools:
+  - repo: dev/alpha
+    version: ^1.0
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+    destinations:
+      "bin/alpha": ` + filepath.Join(dir, "alpha") + `
+  - repo: dev/beta
+    version: latest
+    asset-url-template: "` + server.URL + `/${AssetName}.tar.gz"
+    destinations:
+      "bin/alpha": ` + filepath.Join(dir, "beta") + `
+`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	p := Plan(context.Background(), m, ApplyOptions{Jobs: 1, Output: &bytes.Buffer{}})
+	if p.Failed() || p.Steps[0].Version != "1.4.0" || p.Steps[1].Version != "2.0.0" {
+		t.Fatalf("expected the plan to report resolved versions, got %+v", p.Steps)
+	}
+
+	results := Apply(context.Background(), m, ApplyOptions{Jobs: 1, Output: &bytes.Buffer{}})
+	if Failed(results) {
+		t.Fatalf("Apply failed: %v", Err(results))
+	}
+	if results[0].Version != "1.4.0" || results[1].Version != "2.0.0" {
+		t.Fatalf("expected results to report resolved versions, got %+v", results)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func tarGz(t *testing.T, name string, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content))}); err != nil {
+		t.Fatalf("failed to write tar header: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("failed to write tar content: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,258 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// DEFAULT_LOCK_FILE is the lock file name used next to the manifest
+const DEFAULT_LOCK_FILE = "nanolayer.lock"
+
+// DefaultLockArchitectures are the architectures resolved when none are given
+var DefaultLockArchitectures = []linuxsystem.Architecture{linuxsystem.X86_64, linuxsystem.ARM64}
+
+// Lockfile pins every tool of a manifest to an exact version and asset
+type Lockfile struct {
+	Version        int          `yaml:"version"`
+	ManifestSHA256 string       `yaml:"manifest-sha256"`
+	Tools          []LockedTool `yaml:"tools"`
+}
+
+// LockedTool is the resolved form of a manifest tool
+type LockedTool struct {
+	Name    string `yaml:"name"`
+	Repo    string `yaml:"repo"`
+	Version string `yaml:"version"`
+	// Assets maps an architecture to the asset installed on it, or an
+	// architecture and libc such as x86_64-musl when the asset URL depends
+	// on the libc
+	Assets map[string]LockedAsset `yaml:"assets"`
+}
+
+// LockedAsset is an asset URL together with its SHA-256
+type LockedAsset struct {
+	URL    string `yaml:"url"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// LockPath returns the lock file used for the manifest at manifestPath
+func LockPath(manifestPath string) string {
+	return filepath.Join(filepath.Dir(manifestPath), DEFAULT_LOCK_FILE)
+}
+
+// Lock resolves every tool of m to an exact version and, for each of the
+// given architectures, to an asset URL and SHA-256
+func Lock(ctx context.Context, m *Manifest, architectures []linuxsystem.Architecture) (*Lockfile, error) {
+	lock := &Lockfile{Version: SCHEMA_VERSION, ManifestSHA256: m.Digest()}
+
+	for _, tool := range m.Tools {
+		version, err := pinVersion(ctx, tool)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tool.Name, err)
+		}
+		logging.Event(slog.Default(), logging.EventResolve).Info("Locking tool", "tool", tool.Name, "version", version)
+
+		locked := LockedTool{Name: tool.Name, Repo: tool.Repo, Version: version, Assets: map[string]LockedAsset{}}
+		for _, architecture := range architectures {
+			opts := tool.InstallOptions(architecture)
+			opts.Version = version
+
+			// Lock both libc variants unless the manifest pins one, the
+			// locking machine's libc says nothing about the target's
+			libcs := []string{""}
+			if tool.libcDependent() {
+				libcs = LOCK_LIBCS
+			}
+			for _, libc := range libcs {
+				if libc != "" {
+					opts.Libc = libc
+				}
+				key := lockKey(architecture, libc)
+				assetURL, err := github.ResolveAssetURL(ctx, opts, architecture)
+				if err != nil {
+					return nil, fmt.Errorf("%s (%s): %w", tool.Name, key, err)
+				}
+				content, err := download.Default.Fetch(ctx, assetURL, opts.Checksum)
+				if err != nil {
+					return nil, fmt.Errorf("%s (%s): %w", tool.Name, key, err)
+				}
+				sum := sha256.Sum256(content)
+				locked.Assets[key] = LockedAsset{URL: assetURL, SHA256: hex.EncodeToString(sum[:])}
+			}
+		}
+		lock.Tools = append(lock.Tools, locked)
+	}
+
+	return lock, nil
+}
+
+// LOCK_LIBCS are the libcs locked for tools whose asset URL depends on it
+var LOCK_LIBCS = []string{"gnu", "musl"}
+
+// lockKey returns the Assets key of architecture, qualified with libc when
+// it is not empty
+func lockKey(architecture linuxsystem.Architecture, libc string) string {
+	if libc == "" {
+		return string(architecture)
+	}
+	return string(architecture) + "-" + libc
+}
+
+// libcDependent reports whether the asset URL of t changes with the detected
+// libc, which is the case when the template uses it and t does not pin one
+func (t Tool) libcDependent() bool {
+	return t.Libc == "" && github.TemplateUsesLibc(t.InstallOptions("").AssetUrlTemplate)
+}
+
+// pinVersion resolves latest and version constraints to an exact release
+func pinVersion(ctx context.Context, tool Tool) (string, error) {
+	if tool.Version == "latest" {
+		release, err := github.GetLatestRelease(ctx, tool.Repo, false)
+		if err != nil {
+			return "", err
+		}
+		return release.TagName, nil
+	}
+	return github.ResolveVersion(ctx, tool.Repo, tool.Version)
+}
+
+// LoadLock reads a lock file
+func LoadLock(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	var lock Lockfile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file: %w", err)
+	}
+	if lock.Version != SCHEMA_VERSION {
+		return nil, fmt.Errorf("unsupported lock file version %d", lock.Version)
+	}
+	return &lock, nil
+}
+
+// Write saves the lock file to path
+func (l *Lockfile) Write(path string) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by nanolayer lock, do not edit.\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// Check reports drift between the lock file and m, and packages of m that
+// are not pinned, since only tools are locked
+func (l *Lockfile) Check(m *Manifest) error {
+	var problems []string
+	if l.ManifestSHA256 != m.Digest() {
+		problems = append(problems, "manifest changed since the lock file was generated")
+	}
+	for _, tool := range m.Tools {
+		if _, ok := l.Tool(tool.Name); !ok {
+			problems = append(problems, fmt.Sprintf("tool %s is not locked", tool.Name))
+		}
+	}
+	for _, locked := range l.Tools {
+		if _, ok := m.Tool(locked.Name); !ok {
+			problems = append(problems, fmt.Sprintf("locked tool %s is not in the manifest", locked.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("lock file is out of date, run nanolayer lock:\n  %s", strings.Join(problems, "\n  "))
+	}
+	if unpinned := unpinnedPackages(m); len(unpinned) > 0 {
+		return fmt.Errorf("the lock file does not pin packages, pin them in the manifest as name=version: %s", strings.Join(unpinned, ", "))
+	}
+	return nil
+}
+
+// unpinnedPackages returns the packages of m not pinned to an exact version
+// with name=version, which a locked install would otherwise leave to the
+// package index
+func unpinnedPackages(m *Manifest) []string {
+	managers := make([]string, 0, len(m.Packages))
+	for manager := range m.Packages {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+
+	var unpinned []string
+	for _, manager := range managers {
+		for _, pkg := range m.Packages[manager] {
+			name, version, ok := strings.Cut(pkg, "=")
+			if !ok || version == "" || strings.ContainsAny(name, "<>~") {
+				unpinned = append(unpinned, manager+":"+pkg)
+			}
+		}
+	}
+	return unpinned
+}
+
+// Tool returns the locked tool with the given name
+func (l *Lockfile) Tool(name string) (*LockedTool, bool) {
+	for i := range l.Tools {
+		if l.Tools[i].Name == name {
+			return &l.Tools[i], true
+		}
+	}
+	return nil, false
+}
+
+// LockedInstallOptions returns the installer options that install exactly the
+// locked asset of tool on architecture
+func (l *Lockfile) LockedInstallOptions(tool Tool, architecture linuxsystem.Architecture) (github.InstallOptions, error) {
+	locked, ok := l.Tool(tool.Name)
+	if !ok {
+		return github.InstallOptions{}, fmt.Errorf("tool %s is not locked", tool.Name)
+	}
+	opts := tool.InstallOptions(architecture)
+	libc := ""
+	if tool.libcDependent() {
+		detected, _ := linuxsystem.GetLibc()
+		libc = detected.ABI()
+		if libc == "" {
+			return github.InstallOptions{}, fmt.Errorf("tool %s is locked per libc and the libc of this system could not be detected, set libc in the manifest", tool.Name)
+		}
+		opts.Libc = libc
+	}
+	asset, ok := locked.Assets[lockKey(architecture, libc)]
+	if !ok {
+		if libc != "" {
+			return github.InstallOptions{}, fmt.Errorf("tool %s is not locked for architecture %s with libc %s", tool.Name, architecture, libc)
+		}
+		return github.InstallOptions{}, fmt.Errorf("tool %s is not locked for architecture %s", tool.Name, architecture)
+	}
+
+	opts.Version = locked.Version
+	// The locked URL has no placeholders, so it renders to itself
+	opts.AssetUrlTemplate = asset.URL
+	opts.Checksum = asset.SHA256
+	return opts, nil
+}
@@ -0,0 +1,166 @@
+package manifest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+func TestLockWriteLoadAndCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("asset for " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	data := `
+version: 1
+tools:
+  - repo: dev/tool
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/${Version}/tool_${Architecture}.tar.gz"
+    architecture-replacements:
+      x86_64: amd64
+`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	lock, err := Lock(context.Background(), m, []linuxsystem.Architecture{linuxsystem.X86_64, linuxsystem.ARM64})
+	if err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+
+	tool, ok := lock.Tool("tool")
+	if !ok || tool.Version != "1.0.0" {
+		t.Fatalf("unexpected locked tool: %+v", tool)
+	}
+	amd64 := tool.Assets["x86_64"]
+	if amd64.URL != server.URL+"/1.0.0/tool_amd64.tar.gz" {
+		t.Fatalf("unexpected locked URL: %q", amd64.URL)
+	}
+	sum := sha256.Sum256([]byte("asset for /1.0.0/tool_amd64.tar.gz"))
+	if amd64.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected locked checksum: %q", amd64.SHA256)
+	}
+
+	path := filepath.Join(t.TempDir(), DEFAULT_LOCK_FILE)
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	loaded, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("LoadLock returned error: %v", err)
+	}
+	if err := loaded.Check(m); err != nil {
+		t.Fatalf("Check returned error for matching manifest: %v", err)
+	}
+
+	opts, err := loaded.LockedInstallOptions(m.Tools[0], linuxsystem.ARM64)
+	if err != nil {
+		t.Fatalf("LockedInstallOptions returned error: %v", err)
+	}
+	if opts.AssetUrlTemplate != server.URL+"/1.0.0/tool_arm64.tar.gz" || opts.Checksum == "" {
+		t.Fatalf("unexpected locked install options: %+v", opts)
+	}
+	if _, err := loaded.LockedInstallOptions(m.Tools[0], linuxsystem.PPC64); err == nil {
+		t.Fatalf("expected error for unlocked architecture")
+	}
+
+	changed, err := Parse([]byte(strings.Replace(data, "1.0.0", "1.1.0", 1)))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if err := loaded.Check(changed); err == nil {
+		t.Fatalf("expected drift error for changed manifest")
+	}
+}
+
+func TestLockLibcDependentAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("asset for " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	data := `
+version: 1
+tools:
+  - repo: dev/tool
+    version: 1.0.0
+    asset-url-template: "` + server.URL + `/tool-${RustTarget}.tar.gz"
+  - repo: dev/pinned
+    version: 1.0.0
+    libc: musl
+    asset-url-template: "` + server.URL + `/pinned-${Libc}.tar.gz"
+`
+	m, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	lock, err := Lock(context.Background(), m, []linuxsystem.Architecture{linuxsystem.X86_64})
+	if err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	tool, _ := lock.Tool("tool")
+	if tool.Assets["x86_64-gnu"].URL != server.URL+"/tool-x86_64-unknown-linux-gnu.tar.gz" ||
+		tool.Assets["x86_64-musl"].URL != server.URL+"/tool-x86_64-unknown-linux-musl.tar.gz" {
+		t.Fatalf("expected both libc variants to be locked, got %+v", tool.Assets)
+	}
+	pinned, _ := lock.Tool("pinned")
+	if len(pinned.Assets) != 1 || pinned.Assets["x86_64"].URL != server.URL+"/pinned-musl.tar.gz" {
+		t.Fatalf("expected the pinned libc to be locked by architecture, got %+v", pinned.Assets)
+	}
+
+	detected, _ := linuxsystem.GetLibc()
+	libc := detected.ABI()
+	if libc == "" {
+		t.Skip("libc of this system could not be detected")
+	}
+	opts, err := lock.LockedInstallOptions(m.Tools[0], linuxsystem.X86_64)
+	if err != nil {
+		t.Fatalf("LockedInstallOptions returned error: %v", err)
+	}
+	if opts.Libc != libc || opts.AssetUrlTemplate != tool.Assets["x86_64-"+libc].URL {
+		t.Fatalf("expected the asset locked for libc %s, got %+v", libc, opts)
+	}
+
+	delete(tool.Assets, "x86_64-"+libc)
+	if _, err := lock.LockedInstallOptions(m.Tools[0], linuxsystem.X86_64); err == nil || !strings.Contains(err.Error(), "with libc "+libc) {
+		t.Fatalf("expected error for a libc that is not locked, got %v", err)
+	}
+}
+
+func TestLockPath(t *testing.T) {
+	if got := LockPath("config/nanolayer.yaml"); got != filepath.Join("config", DEFAULT_LOCK_FILE) {
+		t.Fatalf("LockPath = %q", got)
+	}
+}
+
+func TestLockCheckRefusesUnpinnedPackages(t *testing.T) {
+	unpinned, err := Parse([]byte("version: 1\npackages:\n  apk: [curl, git=2.45.2-r0, jq>=1.7]\n"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	lock := &Lockfile{Version: SCHEMA_VERSION, ManifestSHA256: unpinned.Digest()}
+	err = lock.Check(unpinned)
+	if err == nil || !strings.Contains(err.Error(), "apk:curl, apk:jq>=1.7") || strings.Contains(err.Error(), "git") {
+		t.Fatalf("expected error for unpinned packages, got %v", err)
+	}
+
+	pinned, err := Parse([]byte("version: 1\npackages:\n  apk: [curl=8.9.1-r0, git=2.45.2-r0]\n"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	lock = &Lockfile{Version: SCHEMA_VERSION, ManifestSHA256: pinned.Digest()}
+	if err := lock.Check(pinned); err != nil {
+		t.Fatalf("Check returned error for pinned packages: %v", err)
+	}
+}
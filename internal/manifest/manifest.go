@@ -0,0 +1,192 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"gopkg.in/yaml.v3"
+)
+
+// DEFAULT_FILE is the manifest read when no file is given
+const DEFAULT_FILE = "nanolayer.yaml"
+
+// SCHEMA_VERSION is the only manifest version currently understood
+const SCHEMA_VERSION = 1
+
+// Manifest is a declarative list of tools and packages to install
+type Manifest struct {
+	Version  int                 `yaml:"version"`
+	Tools    []Tool              `yaml:"tools"`
+	Packages map[string][]string `yaml:"packages"`
+
+	// digest is the SHA-256 of the manifest source, used to detect drift
+	digest string
+}
+
+// Tool describes a single tool installed from a release source
+type Tool struct {
+	Name                     string            `yaml:"name"`
+	Source                   string            `yaml:"source"`
+	Repo                     string            `yaml:"repo"`
+	Version                  string            `yaml:"version"`
+	AssetName                string            `yaml:"asset-name"`
+	AssetUrlTemplate         string            `yaml:"asset-url-template"`
+	ArchitectureReplacements map[string]string `yaml:"architecture-replacements"`
+	Destinations             map[string]string `yaml:"destinations"`
+	// Libc forces the "gnu" or "musl" asset instead of the detected libc
+	Libc string `yaml:"libc"`
+	// VerifyCommand and ExpectVersion check the tool before it is installed
+	VerifyCommand string `yaml:"verify-command"`
+	ExpectVersion string `yaml:"expect-version"`
+	// Checksums maps an architecture to the expected SHA-256 of its asset
+	Checksums map[string]string `yaml:"checksums"`
+}
+
+// Source types a tool can be installed from
+const (
+	SourceGitHub = "github"
+)
+
+// Package managers usable in the packages section
+var packageManagers = []string{"apk"}
+
+var (
+	repoPattern     = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	checksumPattern = regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`)
+)
+
+// Load reads, decodes and validates a manifest file
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a manifest. Unknown fields are rejected.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	m.applyDefaults()
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	m.digest = hex.EncodeToString(sum[:])
+	return &m, nil
+}
+
+// Digest returns the SHA-256 of the manifest source
+func (m *Manifest) Digest() string {
+	return m.digest
+}
+
+// Tool returns the tool with the given name
+func (m *Manifest) Tool(name string) (*Tool, bool) {
+	for i := range m.Tools {
+		if m.Tools[i].Name == name {
+			return &m.Tools[i], true
+		}
+	}
+	return nil, false
+}
+
+func (m *Manifest) applyDefaults() {
+	for i := range m.Tools {
+		tool := &m.Tools[i]
+		if tool.Source == "" {
+			tool.Source = SourceGitHub
+		}
+		if tool.Version == "" {
+			tool.Version = "latest"
+		}
+		if tool.AssetName == "" && tool.Repo != "" {
+			tool.AssetName = tool.Repo[strings.LastIndex(tool.Repo, "/")+1:]
+		}
+		if tool.Name == "" {
+			tool.Name = tool.AssetName
+		}
+	}
+}
+
+// Validate checks the manifest against the schema and reports every problem
+func (m *Manifest) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if m.Version != SCHEMA_VERSION {
+		addProblem("version: must be %d", SCHEMA_VERSION)
+	}
+	if len(m.Tools) == 0 && len(m.Packages) == 0 {
+		addProblem("manifest must declare at least one tool or package")
+	}
+
+	names := make(map[string]int)
+	for i, tool := range m.Tools {
+		field := fmt.Sprintf("tools[%d]", i)
+		if tool.Source != SourceGitHub {
+			addProblem("%s.source: unsupported source %q", field, tool.Source)
+		}
+		if !repoPattern.MatchString(tool.Repo) {
+			addProblem("%s.repo: must be in the format 'owner/repo'", field)
+		}
+		if previous, ok := names[tool.Name]; ok {
+			addProblem("%s.name: %q is already used by tools[%d]", field, tool.Name, previous)
+		} else {
+			names[tool.Name] = i
+		}
+		for pattern, destination := range tool.Destinations {
+			if !strings.HasPrefix(destination, "/") && !strings.HasPrefix(destination, "~/") {
+				addProblem("%s.destinations[%s]: must be an absolute path or start with ~/", field, pattern)
+			}
+		}
+		if tool.Libc != "" && !github.IsLibc(tool.Libc) {
+			addProblem("%s.libc: must be gnu or musl", field)
+		}
+		for arch, checksum := range tool.Checksums {
+			if !checksumPattern.MatchString(checksum) {
+				addProblem("%s.checksums[%s]: must be a hex SHA-256", field, arch)
+			}
+		}
+	}
+
+	for manager, packages := range m.Packages {
+		if !isPackageManager(manager) {
+			addProblem("packages.%s: unsupported package manager, expected one of %s", manager, strings.Join(packageManagers, ", "))
+		}
+		if len(packages) == 0 {
+			addProblem("packages.%s: must list at least one package", manager)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New("invalid manifest:\n  " + strings.Join(problems, "\n  "))
+}
+
+func isPackageManager(name string) bool {
+	for _, manager := range packageManagers {
+		if manager == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+const validManifest = `
+version: 1
+tools:
+  - repo: charmbracelet/gum
+    version: "^0.14"
+    architecture-replacements:
+      x86_64: amd64
+    checksums:
+      amd64: 0000000000000000000000000000000000000000000000000000000000000000
+  - name: rg
+    repo: BurntSushi/ripgrep
+    asset-url-template: "https://github.com/{{ .Repo }}/releases/download/{{ .Version }}/ripgrep-{{ .Version }}-{{ .Architecture }}-unknown-linux-musl.tar.gz"
+    destinations:
+      "*/rg": /usr/local/bin/rg
+packages:
+  apk: [curl, git]
+`
+
+func TestParseAppliesDefaults(t *testing.T) {
+	m, err := Parse([]byte(validManifest))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if len(m.Tools) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(m.Tools))
+	}
+	gum := m.Tools[0]
+	if gum.Name != "gum" || gum.AssetName != "gum" || gum.Source != SourceGitHub {
+		t.Fatalf("unexpected defaults for gum: %+v", gum)
+	}
+	if m.Tools[1].Version != "latest" {
+		t.Fatalf("expected default version latest, got %q", m.Tools[1].Version)
+	}
+	if len(m.Packages["apk"]) != 2 {
+		t.Fatalf("expected 2 apk packages, got %v", m.Packages["apk"])
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	_, err := Parse([]byte("version: 1\ntools:\n  - repo: a/b\n    verison: 1.0.0\n"))
+	if err == nil || !strings.Contains(err.Error(), "verison") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	data := `
+version: 2
+tools:
+  - repo: not-a-repo
+    source: gitlab
+    destinations:
+      "*/tool": relative/path
+    checksums:
+      amd64: abc
+  - name: not-a-repo
+    repo: a/b
+    libc: uclibc
+packages:
+  yum: [curl]
+`
+	_, err := Parse([]byte(data))
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{
+		"version: must be 1",
+		"tools[0].repo",
+		"tools[0].source",
+		"tools[0].destinations[*/tool]",
+		"tools[0].checksums[amd64]",
+		"tools[1].name",
+		"tools[1].libc",
+		"packages.yum",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DEFAULT_FILE)
+	if err := os.WriteFile(path, []byte(validManifest), 0o644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatalf("expected error for missing manifest")
+	}
+}
+
+func TestToolInstallOptions(t *testing.T) {
+	m, err := Parse([]byte(validManifest))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	opts := m.Tools[0].InstallOptions(linuxsystem.X86_64)
+	if opts.AssetUrlTemplate != github.DefaultAssetUrlTemplate {
+		t.Fatalf("expected default asset URL template, got %q", opts.AssetUrlTemplate)
+	}
+	if len(opts.FileDestinations) != 0 {
+		t.Fatalf("expected automatic destinations, got %v", opts.FileDestinations)
+	}
+	if opts.Checksum != strings.Repeat("0", 64) {
+		t.Fatalf("expected checksum resolved through architecture replacement, got %q", opts.Checksum)
+	}
+
+	if other := m.Tools[0].InstallOptions(linuxsystem.ARM64); other.Checksum != "" {
+		t.Fatalf("expected no checksum for arm64, got %q", other.Checksum)
+	}
+}
@@ -0,0 +1,115 @@
+package parallel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrSkipped is returned for tasks not started because an earlier task failed
+// in fail-fast mode
+var ErrSkipped = errors.New("skipped after an earlier failure")
+
+// Task is a unit of work that writes its output to out
+type Task func(out io.Writer) error
+
+// Options configures Run
+type Options struct {
+	// Jobs is the maximum number of tasks running at once, 1 if less than 1
+	Jobs int
+	// FailFast stops starting new tasks after the first failure
+	FailFast bool
+	// Output receives the output of every task, discarded if nil
+	Output io.Writer
+}
+
+// Run executes tasks on a bounded worker pool and returns their errors in
+// task order. Each task's output is buffered and written to opts.Output in
+// task order, as soon as the task and all tasks before it have finished, so
+// the combined output does not depend on scheduling. With a single job tasks
+// run in order and write to opts.Output directly. Tasks not yet started when
+// ctx is done are not run and get ctx.Err() as their error.
+func Run(ctx context.Context, tasks []Task, opts Options) []error {
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+
+	errs := make([]error, len(tasks))
+	if jobs == 1 {
+		failed := false
+		for index, task := range tasks {
+			if opts.FailFast && failed {
+				errs[index] = ErrSkipped
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				errs[index] = err
+				continue
+			}
+			errs[index] = task(output)
+			failed = failed || errs[index] != nil
+		}
+		return errs
+	}
+
+	buffers := make([]bytes.Buffer, len(tasks))
+	done := make([]bool, len(tasks))
+
+	var mu sync.Mutex
+	failed := false
+	flushed := 0
+	// flush writes the output of finished tasks in order, mu must be held
+	flush := func() {
+		for flushed < len(tasks) && done[flushed] {
+			output.Write(buffers[flushed].Bytes())
+			flushed++
+		}
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < jobs; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				mu.Lock()
+				skip := opts.FailFast && failed
+				mu.Unlock()
+
+				var err error
+				if skip {
+					err = ErrSkipped
+				} else if ctx.Err() != nil {
+					err = ctx.Err()
+				} else {
+					err = tasks[index](&buffers[index])
+				}
+
+				mu.Lock()
+				errs[index] = err
+				done[index] = true
+				if err != nil && err != ErrSkipped {
+					failed = true
+				}
+				flush()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for index := range tasks {
+		queue <- index
+	}
+	close(queue)
+	wg.Wait()
+
+	return errs
+}
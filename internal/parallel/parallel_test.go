@@ -0,0 +1,117 @@
+package parallel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunOrdersOutput(t *testing.T) {
+	var tasks []Task
+	for i := 0; i < 5; i++ {
+		delay := time.Duration(5-i) * 5 * time.Millisecond
+		index := i
+		tasks = append(tasks, func(out io.Writer) error {
+			time.Sleep(delay)
+			fmt.Fprintf(out, "task %d\n", index)
+			return nil
+		})
+	}
+
+	var out bytes.Buffer
+	errs := Run(context.Background(), tasks, Options{Jobs: 5, Output: &out})
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("task %d returned error: %v", i, err)
+		}
+	}
+	want := "task 0\ntask 1\ntask 2\ntask 3\ntask 4\n"
+	if out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	var tasks []Task
+	for i := 0; i < 10; i++ {
+		tasks = append(tasks, func(out io.Writer) error {
+			current := running.Add(1)
+			for {
+				previous := peak.Load()
+				if current <= previous || peak.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+
+	Run(context.Background(), tasks, Options{Jobs: 3})
+	if peak.Load() > 3 {
+		t.Fatalf("expected at most 3 concurrent tasks, got %d", peak.Load())
+	}
+}
+
+func TestRunCollectsAllErrors(t *testing.T) {
+	boom := errors.New("boom")
+	tasks := []Task{
+		func(out io.Writer) error { return boom },
+		func(out io.Writer) error { return nil },
+		func(out io.Writer) error { return boom },
+	}
+
+	errs := Run(context.Background(), tasks, Options{Jobs: 1})
+	if errs[0] != boom || errs[1] != nil || errs[2] != boom {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestRunFailFastSkipsRemainingTasks(t *testing.T) {
+	boom := errors.New("boom")
+	var ran atomic.Int32
+	tasks := []Task{
+		func(out io.Writer) error { ran.Add(1); return boom },
+		func(out io.Writer) error { ran.Add(1); return nil },
+		func(out io.Writer) error { ran.Add(1); return nil },
+	}
+
+	errs := Run(context.Background(), tasks, Options{Jobs: 1, FailFast: true})
+	if errs[0] != boom || errs[1] != ErrSkipped || errs[2] != ErrSkipped {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if ran.Load() != 1 {
+		t.Fatalf("expected only the first task to run, ran %d", ran.Load())
+	}
+}
+
+func TestRunCanceledSkipsRemainingTasks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var ran atomic.Int32
+	tasks := []Task{
+		func(out io.Writer) error { ran.Add(1); cancel(); return nil },
+		func(out io.Writer) error { ran.Add(1); return nil },
+	}
+
+	errs := Run(ctx, tasks, Options{Jobs: 1})
+	if errs[0] != nil || !errors.Is(errs[1], context.Canceled) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if ran.Load() != 1 {
+		t.Fatalf("expected only the first task to run, ran %d", ran.Load())
+	}
+
+	// With several workers no task starts once the context is done
+	errs = Run(ctx, tasks, Options{Jobs: 2})
+	if !errors.Is(errs[0], context.Canceled) || !errors.Is(errs[1], context.Canceled) || ran.Load() != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
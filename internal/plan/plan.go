@@ -0,0 +1,100 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Plan lists what a dry run would do, without having done any of it
+type Plan struct {
+	Steps []Step `json:"steps"`
+}
+
+// Step is the planned work of a single installer invocation
+type Step struct {
+	Installer string     `json:"installer"`
+	Name      string     `json:"name"`
+	Version   string     `json:"version,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	Packages  []string   `json:"packages,omitempty"`
+	Writes    []string   `json:"writes,omitempty"`
+	Removes   []string   `json:"removes,omitempty"`
+	Commands  [][]string `json:"commands,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	// Err is the error behind Error, kept for exit codes
+	Err error `json:"-"`
+}
+
+// Fail records err as the reason the step could not be planned
+func (s *Step) Fail(err error) {
+	s.Err = err
+	s.Error = err.Error()
+}
+
+// Add appends steps to the plan
+func (p *Plan) Add(steps ...Step) {
+	p.Steps = append(p.Steps, steps...)
+}
+
+// Failed reports whether any step could not be planned
+func (p *Plan) Failed() bool {
+	return p.Err() != nil
+}
+
+// Err returns the error of the first step that could not be planned
+func (p *Plan) Err() error {
+	for _, step := range p.Steps {
+		if step.Err != nil {
+			return fmt.Errorf("%s: %w", step.Name, step.Err)
+		}
+		if step.Error != "" {
+			return fmt.Errorf("%s: %s", step.Name, step.Error)
+		}
+	}
+	return nil
+}
+
+// Write renders the plan to w in the given format, "text" or "json"
+func (p *Plan) Write(w io.Writer, format string) error {
+	switch format {
+	case "", "text":
+		p.writeText(w)
+		return nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(p)
+	default:
+		return fmt.Errorf("unsupported output format %q, expected text or json", format)
+	}
+}
+
+func (p *Plan) writeText(w io.Writer) {
+	for _, step := range p.Steps {
+		title := fmt.Sprintf("%s: %s", step.Installer, step.Name)
+		if step.Version != "" {
+			title += " " + step.Version
+		}
+		fmt.Fprintln(w, title)
+		if step.URL != "" {
+			fmt.Fprintf(w, "  download %s\n", step.URL)
+		}
+		for _, pkg := range step.Packages {
+			fmt.Fprintf(w, "  install package %s\n", pkg)
+		}
+		for _, command := range step.Commands {
+			fmt.Fprintf(w, "  run %s\n", strings.Join(command, " "))
+		}
+		for _, path := range step.Writes {
+			fmt.Fprintf(w, "  write %s\n", path)
+		}
+		for _, path := range step.Removes {
+			fmt.Fprintf(w, "  remove %s\n", path)
+		}
+		if step.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", step.Error)
+		}
+	}
+}
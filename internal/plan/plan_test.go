@@ -0,0 +1,92 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	p := &Plan{}
+	p.Add(Step{
+		Installer: "github",
+		Name:      "dev/tool",
+		Version:   "1.0.0",
+		URL:       "https://example.com/tool.tar.gz",
+		Writes:    []string{"/usr/local/bin/tool"},
+	}, Step{
+		Installer: "apk",
+		Name:      "packages",
+		Packages:  []string{"curl"},
+		Commands:  [][]string{{"apk", "add", "--no-cache", "curl"}},
+		Removes:   []string{"/var/cache/apk"},
+	})
+
+	var out bytes.Buffer
+	if err := p.Write(&out, "text"); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	for _, want := range []string{
+		"github: dev/tool 1.0.0",
+		"download https://example.com/tool.tar.gz",
+		"write /usr/local/bin/tool",
+		"install package curl",
+		"run apk add --no-cache curl",
+		"remove /var/cache/apk",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	p := &Plan{}
+	p.Add(Step{Installer: "github", Name: "dev/tool", Error: "not found"})
+
+	var out bytes.Buffer
+	if err := p.Write(&out, "json"); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	var decoded Plan
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(decoded.Steps) != 1 || decoded.Steps[0].Error != "not found" {
+		t.Fatalf("unexpected decoded plan: %+v", decoded)
+	}
+	if !decoded.Failed() {
+		t.Fatalf("expected plan with error to be failed")
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	if err := (&Plan{}).Write(&bytes.Buffer{}, "yaml"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
+
+func TestErrKeepsStepError(t *testing.T) {
+	notFound := errors.New("release not found")
+	p := &Plan{}
+	p.Add(Step{Installer: "apk", Name: "curl"})
+	if p.Failed() || p.Err() != nil {
+		t.Fatalf("expected no error, got %v", p.Err())
+	}
+
+	failed := Step{Installer: "github", Name: "dev/tool"}
+	failed.Fail(notFound)
+	p.Add(failed, Step{Installer: "github", Name: "dev/other", Error: "boom"})
+	if !p.Failed() || !errors.Is(p.Err(), notFound) || p.Err().Error() != "dev/tool: release not found" {
+		t.Fatalf("expected the first step error, got %v", p.Err())
+	}
+
+	// Err is not part of the JSON plan
+	var out bytes.Buffer
+	if err := p.Write(&out, "json"); err != nil || strings.Contains(out.String(), `"Err"`) {
+		t.Fatalf("unexpected JSON plan %s (%v)", out.String(), err)
+	}
+}
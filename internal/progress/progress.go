@@ -0,0 +1,196 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reporter receives progress events for a transfer.
+//
+// Start is called once before the first Update with the expected total size
+// in bytes, or -1 when unknown. Update is called with the number of bytes
+// transferred so far and Finish once the transfer ended, with its error.
+type Reporter interface {
+	Start(name string, total int64)
+	Update(current int64)
+	Finish(err error)
+}
+
+// Nop is a Reporter that discards all events
+type Nop struct{}
+
+func (Nop) Start(name string, total int64) {}
+func (Nop) Update(current int64)           {}
+func (Nop) Finish(err error)               {}
+
+// New returns a Reporter writing to w: a redrawn bar when w is a terminal,
+// periodic plain-text lines otherwise, or Nop when quiet is set.
+func New(w io.Writer, quiet bool) Reporter {
+	if quiet {
+		return Nop{}
+	}
+	if isTerminal(w) {
+		return &textReporter{out: w, bar: true, interval: 100 * time.Millisecond}
+	}
+	return &textReporter{out: w, interval: 5 * time.Second}
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// textReporter renders progress either as a bar redrawn in place or as
+// periodic log lines, at most once per interval
+type textReporter struct {
+	out      io.Writer
+	bar      bool
+	interval time.Duration
+
+	mu        sync.Mutex
+	name      string
+	total     int64
+	current   int64
+	started   time.Time
+	lastDrawn time.Time
+}
+
+func (r *textReporter) Start(name string, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.name = name
+	r.total = total
+	r.current = 0
+	r.started = time.Now()
+	r.lastDrawn = time.Time{}
+}
+
+func (r *textReporter) Update(current int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current = current
+	now := time.Now()
+	if now.Sub(r.lastDrawn) < r.interval {
+		return
+	}
+	r.lastDrawn = now
+	r.draw(now)
+}
+
+func (r *textReporter) Finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if r.bar {
+		r.draw(now)
+		fmt.Fprintln(r.out)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(r.out, "%s: failed after %s: %v\n", r.name, formatBytes(r.current), err)
+		return
+	}
+	fmt.Fprintf(r.out, "%s: downloaded %s in %s\n", r.name, formatBytes(r.current), now.Sub(r.started).Round(100*time.Millisecond))
+}
+
+func (r *textReporter) draw(now time.Time) {
+	stats := Compute(r.current, r.total, now.Sub(r.started))
+	if r.bar {
+		fmt.Fprintf(r.out, "\r%s %s %s", r.name, renderBar(stats.Fraction, 30), stats)
+		return
+	}
+	fmt.Fprintf(r.out, "%s: %s\n", r.name, stats)
+}
+
+// Stats summarizes a transfer at a point in time
+type Stats struct {
+	Current int64
+	Total   int64
+	// Fraction is the completed share in [0, 1], or -1 when the total is unknown
+	Fraction float64
+	// Rate is the average speed in bytes per second
+	Rate float64
+	// ETA is the estimated remaining time, or -1 when it cannot be estimated
+	ETA time.Duration
+}
+
+// Compute derives transfer statistics from the bytes transferred so far
+func Compute(current int64, total int64, elapsed time.Duration) Stats {
+	stats := Stats{Current: current, Total: total, Fraction: -1, ETA: -1}
+	if elapsed > 0 {
+		stats.Rate = float64(current) / elapsed.Seconds()
+	}
+	if total > 0 {
+		stats.Fraction = float64(current) / float64(total)
+		if stats.Fraction > 1 {
+			stats.Fraction = 1
+		}
+		if stats.Rate > 0 {
+			remaining := float64(total-current) / stats.Rate
+			stats.ETA = time.Duration(remaining * float64(time.Second)).Round(time.Second)
+		}
+	}
+	return stats
+}
+
+func (s Stats) String() string {
+	rate := formatBytes(int64(s.Rate)) + "/s"
+	if s.Total <= 0 {
+		return fmt.Sprintf("%s %s", formatBytes(s.Current), rate)
+	}
+	eta := "?"
+	if s.ETA >= 0 {
+		eta = s.ETA.String()
+	}
+	return fmt.Sprintf("%3.0f%% %s/%s %s ETA %s", s.Fraction*100, formatBytes(s.Current), formatBytes(s.Total), rate, eta)
+}
+
+func renderBar(fraction float64, width int) string {
+	if fraction < 0 {
+		return "[" + strings.Repeat("?", width) + "]"
+	}
+	filled := int(fraction * float64(width))
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Reader wraps an io.Reader and reports the bytes read to a Reporter
+type Reader struct {
+	Reader   io.Reader
+	Reporter Reporter
+	// Offset is the number of bytes already transferred before reading started
+	Offset int64
+	read   int64
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+		r.Reporter.Update(r.Offset + r.read)
+	}
+	return n, err
+}
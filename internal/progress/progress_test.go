@@ -0,0 +1,87 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	stats := Compute(50, 200, 10*time.Second)
+	if stats.Fraction != 0.25 {
+		t.Fatalf("Fraction = %v, want 0.25", stats.Fraction)
+	}
+	if stats.Rate != 5 {
+		t.Fatalf("Rate = %v, want 5", stats.Rate)
+	}
+	if stats.ETA != 30*time.Second {
+		t.Fatalf("ETA = %v, want 30s", stats.ETA)
+	}
+
+	unknown := Compute(50, -1, time.Second)
+	if unknown.Fraction != -1 || unknown.ETA != -1 {
+		t.Fatalf("expected unknown fraction and ETA, got %+v", unknown)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512 B",
+		2048:            "2.0 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+	}
+	for input, want := range tests {
+		if got := formatBytes(input); got != want {
+			t.Fatalf("formatBytes(%d) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNewQuietIsNop(t *testing.T) {
+	if _, ok := New(&bytes.Buffer{}, true).(Nop); !ok {
+		t.Fatalf("expected Nop reporter when quiet")
+	}
+}
+
+func TestLineReporterWritesSummary(t *testing.T) {
+	var out bytes.Buffer
+	reporter := New(&out, false)
+
+	reporter.Start("tool.tar.gz", 4)
+	reporter.Update(4)
+	reporter.Finish(nil)
+
+	if !strings.Contains(out.String(), "tool.tar.gz: downloaded 4 B") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	out.Reset()
+	reporter.Start("tool.tar.gz", 4)
+	reporter.Finish(errors.New("connection reset"))
+	if !strings.Contains(out.String(), "failed") {
+		t.Fatalf("expected failure line, got %q", out.String())
+	}
+}
+
+func TestReaderReportsProgress(t *testing.T) {
+	recorder := &recordingReporter{}
+	reader := &Reader{Reader: strings.NewReader("hello world"), Reporter: recorder}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("copy returned error: %v", err)
+	}
+	if len(recorder.updates) == 0 || recorder.updates[len(recorder.updates)-1] != 11 {
+		t.Fatalf("expected final update of 11 bytes, got %v", recorder.updates)
+	}
+}
+
+type recordingReporter struct {
+	updates []int64
+}
+
+func (r *recordingReporter) Start(name string, total int64) {}
+func (r *recordingReporter) Update(current int64)           { r.updates = append(r.updates, current) }
+func (r *recordingReporter) Finish(err error)               {}
@@ -0,0 +1,291 @@
+package shellenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+)
+
+// Shell is the syntax a startup file is written in
+type Shell string
+
+const (
+	// POSIX covers sh, bash and zsh
+	POSIX Shell = "posix"
+	Fish  Shell = "fish"
+)
+
+// StartupFile is a shell startup file managed by nanolayer
+type StartupFile struct {
+	Path  string
+	Shell Shell
+}
+
+// Entry is a PATH directory or an environment variable kept in a block of
+// every startup file
+type Entry struct {
+	// Name is the directory for PATH entries and the variable name otherwise
+	Name  string
+	Value string
+	path  bool
+}
+
+// PathEntry prepends dir to PATH, which must be absolute
+func PathEntry(dir string) Entry {
+	return Entry{Name: filepath.Clean(dir), path: true}
+}
+
+// ParsePath parses a directory for PATH, making a relative one absolute
+// since startup files are read from any working directory
+func ParsePath(dir string) (Entry, error) {
+	if dir == "" {
+		return Entry{}, fmt.Errorf("empty PATH directory")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid PATH directory %q: %w", dir, err)
+	}
+	return PathEntry(abs), nil
+}
+
+// VarEntry exports the variable key with value
+func VarEntry(key string, value string) Entry {
+	return Entry{Name: key, Value: value}
+}
+
+// ParseVar parses a KEY=VALUE assignment
+func ParseVar(assignment string) (Entry, error) {
+	key, value, ok := strings.Cut(assignment, "=")
+	if !ok || !variablePattern.MatchString(key) {
+		return Entry{}, fmt.Errorf("invalid assignment %q, expected KEY=VALUE", assignment)
+	}
+	return VarEntry(key, value), nil
+}
+
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ID identifies the block of an entry in the startup files
+func (e Entry) ID() string {
+	if e.path {
+		return "path " + e.Name
+	}
+	return "env " + e.Name
+}
+
+// render returns the statements of e in the syntax of shell
+func (e Entry) render(shell Shell) string {
+	switch {
+	case e.path && shell == Fish:
+		return fmt.Sprintf("contains %s $PATH; or set -gx PATH %s $PATH", fishQuote(e.Name), fishQuote(e.Name))
+	case e.path:
+		return fmt.Sprintf("case \":$PATH:\" in *:%s:*) ;; *) export PATH=%s\"${PATH:+:$PATH}\" ;; esac", shQuote(e.Name), shQuote(e.Name))
+	case shell == Fish:
+		return fmt.Sprintf("set -gx %s %s", e.Name, fishQuote(e.Value))
+	default:
+		return fmt.Sprintf("export %s=%s", e.Name, shQuote(e.Value))
+	}
+}
+
+func shQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func fishQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// StartupFiles returns the startup files entries are written to for target:
+// /etc/profile.d and the system bash, zsh and fish configuration for the
+// system scope, or ~/.profile, ~/.bashrc, ~/.zshrc and the fish conf.d of the
+// user for the user scope. Zsh and fish files are only used when the shell is
+// configured. A nil target selects the system scope.
+func StartupFiles(target *installers.Target) []StartupFile {
+	return startupFiles("/", target)
+}
+
+func startupFiles(root string, target *installers.Target) []StartupFile {
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(root, path))
+		return err == nil
+	}
+
+	if target == nil || target.Scope != installers.ScopeUser || target.User == nil {
+		files := []StartupFile{{"/etc/profile.d/nanolayer.sh", POSIX}}
+		if exists("/etc/bash.bashrc") {
+			files = append(files, StartupFile{"/etc/bash.bashrc", POSIX})
+		}
+		switch {
+		case exists("/etc/zsh"):
+			files = append(files, StartupFile{"/etc/zsh/zshenv", POSIX})
+		case exists("/etc/zshenv"):
+			files = append(files, StartupFile{"/etc/zshenv", POSIX})
+		}
+		if exists("/etc/fish") {
+			files = append(files, StartupFile{"/etc/fish/conf.d/nanolayer.fish", Fish})
+		}
+		return withRoot(root, files)
+	}
+
+	home := target.User.Home
+	files := []StartupFile{
+		{filepath.Join(home, ".profile"), POSIX},
+		{filepath.Join(home, ".bashrc"), POSIX},
+	}
+	if exists(filepath.Join(home, ".zshrc")) || strings.HasSuffix(target.User.Shell, "/zsh") {
+		files = append(files, StartupFile{filepath.Join(home, ".zshrc"), POSIX})
+	}
+	if exists(filepath.Join(home, ".config", "fish")) || strings.HasSuffix(target.User.Shell, "/fish") {
+		files = append(files, StartupFile{filepath.Join(home, ".config", "fish", "conf.d", "nanolayer.fish"), Fish})
+	}
+	return withRoot(root, files)
+}
+
+func withRoot(root string, files []StartupFile) []StartupFile {
+	for i := range files {
+		files[i].Path = filepath.Join(root, files[i].Path)
+	}
+	return files
+}
+
+// Add writes entry into every startup file of target, replacing an earlier
+// block for the same entry. It returns the files that changed.
+func Add(ctx context.Context, target *installers.Target, entry Entry) ([]string, error) {
+	return update(ctx, target, StartupFiles(target), entry.ID(), func(shell Shell) string {
+		return entry.render(shell)
+	})
+}
+
+// Remove deletes the blocks with the given entry IDs from every startup file
+// of target. It returns the files that changed.
+func Remove(ctx context.Context, target *installers.Target, ids ...string) ([]string, error) {
+	var changed []string
+	for _, id := range ids {
+		files, err := update(ctx, target, StartupFiles(target), id, func(Shell) string { return "" })
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, files...)
+	}
+	return changed, nil
+}
+
+// LOCK_POLL_INTERVAL is how often a locked startup file is retried
+const LOCK_POLL_INTERVAL = 50 * time.Millisecond
+
+// updateMu serializes the read-modify-write of startup files within the
+// process, since tools installed in parallel add to PATH concurrently
+var updateMu sync.Mutex
+
+func update(ctx context.Context, target *installers.Target, files []StartupFile, id string, render func(Shell) string) ([]string, error) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	var changed []string
+	for _, file := range files {
+		fileChanged, err := updateFile(ctx, target, file, id, render(file.Shell))
+		if err != nil {
+			return changed, err
+		}
+		if fileChanged {
+			changed = append(changed, file.Path)
+		}
+	}
+	return changed, nil
+}
+
+// updateFile replaces the block id of file with body while holding an flock
+// on the file, so other processes do not interleave their updates
+func updateFile(ctx context.Context, target *installers.Target, file StartupFile, id string, body string) (bool, error) {
+	unlock, err := lockFile(ctx, file.Path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	content, err := os.ReadFile(file.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %w", file.Path, err)
+	}
+	updated := UpdateBlock(string(content), id, body)
+	if updated == string(content) {
+		return false, nil
+	}
+	if err := installers.WriteFile(ctx, file.Path, []byte(updated), 0644); err != nil {
+		return false, err
+	}
+	if err := target.Chown(file.Path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// lockFile takes an exclusive flock on path, waiting while another process
+// holds it. A file that does not exist yet is only guarded by updateMu. The
+// returned function releases the lock.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return func() {}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(LOCK_POLL_INTERVAL):
+		}
+	}
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+// UpdateBlock replaces the marker-delimited block id in content with body,
+// appends it if missing, or removes it if body is empty
+func UpdateBlock(content string, id string, body string) string {
+	begin := "# >>> nanolayer " + id + " >>>"
+	end := "# <<< nanolayer " + id + " <<<"
+	block := ""
+	if body != "" {
+		block = begin + "\n" + body + "\n" + end + "\n"
+	}
+
+	start := strings.Index(content, begin+"\n")
+	if start >= 0 {
+		if stop := strings.Index(content[start:], end); stop >= 0 {
+			stop += start + len(end)
+			if stop < len(content) && content[stop] == '\n' {
+				stop++
+			}
+			return content[:start] + block + content[stop:]
+		}
+	}
+	if block == "" {
+		return content
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + block
+}
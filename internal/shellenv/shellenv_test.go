@@ -0,0 +1,189 @@
+package shellenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+func TestUpdateBlock(t *testing.T) {
+	original := "alias ll='ls -l'"
+	added := UpdateBlock(original, "path /opt/bin", "export PATH=/opt/bin:$PATH")
+	want := "alias ll='ls -l'\n# >>> nanolayer path /opt/bin >>>\nexport PATH=/opt/bin:$PATH\n# <<< nanolayer path /opt/bin <<<\n"
+	if added != want {
+		t.Fatalf("UpdateBlock added %q, want %q", added, want)
+	}
+
+	if again := UpdateBlock(added, "path /opt/bin", "export PATH=/opt/bin:$PATH"); again != added {
+		t.Fatalf("expected UpdateBlock to be idempotent, got %q", again)
+	}
+
+	replaced := UpdateBlock(added+"echo done\n", "path /opt/bin", "export PATH=/srv/bin:$PATH")
+	if !strings.Contains(replaced, "/srv/bin") || strings.Contains(replaced, "/opt/bin:$PATH") || !strings.HasSuffix(replaced, "echo done\n") {
+		t.Fatalf("unexpected replaced content: %q", replaced)
+	}
+
+	if removed := UpdateBlock(added, "path /opt/bin", ""); removed != original+"\n" {
+		t.Fatalf("UpdateBlock removed to %q, want %q", removed, original+"\n")
+	}
+}
+
+func TestEntryRender(t *testing.T) {
+	tests := []struct {
+		entry Entry
+		shell Shell
+		want  string
+	}{
+		{PathEntry("/opt/tool/bin/"), POSIX, `case ":$PATH:" in *:'/opt/tool/bin':*) ;; *) export PATH='/opt/tool/bin'"${PATH:+:$PATH}" ;; esac`},
+		{PathEntry("/opt/tool/bin"), Fish, `contains '/opt/tool/bin' $PATH; or set -gx PATH '/opt/tool/bin' $PATH`},
+		{VarEntry("GREETING", "it's here"), POSIX, `export GREETING='it'\''s here'`},
+		{VarEntry("GREETING", "it's here"), Fish, `set -gx GREETING 'it\'s here'`},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.render(tt.shell); got != tt.want {
+			t.Fatalf("render(%s) = %q, want %q", tt.shell, got, tt.want)
+		}
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	entry, err := ParseVar("GOPATH=/go=x")
+	if err != nil || entry.Name != "GOPATH" || entry.Value != "/go=x" {
+		t.Fatalf("unexpected entry %+v (%v)", entry, err)
+	}
+	for _, invalid := range []string{"novalue", "1KEY=value", "=value"} {
+		if _, err := ParseVar(invalid); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	entry, err := ParsePath("tool/bin/")
+	if err != nil || entry.Name != filepath.Join(wd, "tool", "bin") {
+		t.Fatalf("expected relative directory to be made absolute, got %+v (%v)", entry, err)
+	}
+	entry, err = ParsePath("/opt/tool/bin")
+	if err != nil || entry.ID() != PathEntry("/opt/tool/bin").ID() {
+		t.Fatalf("unexpected entry %+v (%v)", entry, err)
+	}
+	if _, err := ParsePath(""); err == nil {
+		t.Fatalf("expected error for empty directory")
+	}
+}
+
+func TestStartupFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "etc", "fish"), 0755); err != nil {
+		t.Fatalf("failed to create fixture: %v", err)
+	}
+
+	var system []string
+	for _, file := range startupFiles(root, nil) {
+		system = append(system, strings.TrimPrefix(file.Path, root))
+	}
+	if strings.Join(system, " ") != "/etc/profile.d/nanolayer.sh /etc/fish/conf.d/nanolayer.fish" {
+		t.Fatalf("unexpected system startup files: %v", system)
+	}
+
+	user := &linuxsystem.User{Name: "vscode", Home: "/home/vscode", Shell: "/usr/bin/zsh"}
+	target := &installers.Target{Scope: installers.ScopeUser, User: user}
+	var userFiles []string
+	for _, file := range startupFiles(root, target) {
+		userFiles = append(userFiles, strings.TrimPrefix(file.Path, root))
+	}
+	if strings.Join(userFiles, " ") != "/home/vscode/.profile /home/vscode/.bashrc /home/vscode/.zshrc" {
+		t.Fatalf("unexpected user startup files: %v", userFiles)
+	}
+}
+
+func TestUpdateWritesAndRemovesBlocks(t *testing.T) {
+	dir := t.TempDir()
+	files := []StartupFile{
+		{filepath.Join(dir, ".bashrc"), POSIX},
+		{filepath.Join(dir, "conf.d", "nanolayer.fish"), Fish},
+	}
+	entry := PathEntry("/opt/tool/bin")
+
+	changed, err := update(context.Background(), nil, files, entry.ID(), entry.render)
+	if err != nil || len(changed) != 2 {
+		t.Fatalf("expected both files to change, got %v (%v)", changed, err)
+	}
+	changed, err = update(context.Background(), nil, files, entry.ID(), entry.render)
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("expected no change on second update, got %v (%v)", changed, err)
+	}
+
+	if _, err := update(context.Background(), nil, files, entry.ID(), func(Shell) string { return "" }); err != nil {
+		t.Fatalf("update returned error: %v", err)
+	}
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil || strings.Contains(string(content), "nanolayer") {
+		t.Fatalf("expected block to be removed, got %q (%v)", string(content), err)
+	}
+}
+
+func TestAddConcurrentEntries(t *testing.T) {
+	home := t.TempDir()
+	target := &installers.Target{Scope: installers.ScopeUser, User: &linuxsystem.User{Name: "vscode", Home: home, Shell: "/bin/bash"}}
+
+	const entries = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, entries)
+	for i := 0; i < entries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Add(context.Background(), target, PathEntry(fmt.Sprintf("/opt/tool%d/bin", i))); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Add returned error: %v", err)
+	}
+
+	for _, name := range []string{".profile", ".bashrc"} {
+		content, err := os.ReadFile(filepath.Join(home, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		for i := 0; i < entries; i++ {
+			if !strings.Contains(string(content), fmt.Sprintf("# >>> nanolayer path /opt/tool%d/bin >>>", i)) {
+				t.Fatalf("expected %s to keep the block of every entry, missing %d in:\n%s", name, i, content)
+			}
+		}
+	}
+}
+
+func TestLockFileWaitsForHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".bashrc")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to create fixture: %v", err)
+	}
+	unlock, err := lockFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("lockFile returned error: %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*LOCK_POLL_INTERVAL)
+	defer cancel()
+	if _, err := lockFile(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected lockFile to wait for the holder, got %v", err)
+	}
+}
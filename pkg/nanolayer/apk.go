@@ -0,0 +1,33 @@
+package nanolayer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/apk"
+)
+
+// APKOptions describes Alpine packages to install
+type APKOptions struct {
+	Packages []string
+}
+
+// InstallAPK installs opts.Packages with apk, leaving no package cache behind
+func InstallAPK(ctx context.Context, opts APKOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(opts.Packages) == 0 {
+		return errors.New("no packages specified")
+	}
+	return apk.InstallPackage(ctx, opts.Packages)
+}
+
+// PlanAPK returns the packages apk would install, including dependencies
+func PlanAPK(ctx context.Context, opts APKOptions) (Step, error) {
+	if err := ctx.Err(); err != nil {
+		return Step{Installer: "apk"}, err
+	}
+	step, err := apk.Plan(ctx, opts.Packages)
+	return newStep(step), err
+}
@@ -0,0 +1,94 @@
+package nanolayer
+
+import (
+	"context"
+	"debug/elf"
+	"encoding/binary"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+)
+
+// ArchiveFile is an entry of an extracted archive
+type ArchiveFile struct {
+	Name    string
+	Content []byte
+	IsDir   bool
+	Kind    FileKind
+	// ELF describes the binary for KindELF entries, nil otherwise
+	ELF *ELFInfo
+}
+
+// FileKind classifies an archive entry as a directory, ELF binary, script or
+// data
+type FileKind string
+
+const (
+	KindDirectory FileKind = "directory"
+	KindELF       FileKind = "elf"
+	KindScript    FileKind = "script"
+	KindData      FileKind = "data"
+)
+
+// ELFInfo is the header information of an ELF binary
+type ELFInfo struct {
+	Type      elf.Type
+	Machine   elf.Machine
+	Class     elf.Class
+	ByteOrder binary.ByteOrder
+	// Interpreter is the dynamic loader, empty for static binaries
+	Interpreter string
+}
+
+// Static reports whether the binary runs without a dynamic loader
+func (i *ELFInfo) Static() bool {
+	return i.Interpreter == ""
+}
+
+// Libc returns the libc the binary is linked against, "gnu" or "musl", or
+// "" for static binaries and unknown loaders
+func (i *ELFInfo) Libc() string {
+	return i.internal().Libc().ABI()
+}
+
+// Matches reports whether the binary runs on architecture
+func (i *ELFInfo) Matches(architecture Architecture) bool {
+	return i.internal().Matches(architecture.internal())
+}
+
+func (i *ELFInfo) internal() *elfinspect.Info {
+	info := elfinspect.Info(*i)
+	return &info
+}
+
+func newArchiveFile(file github.ArchiveFile) ArchiveFile {
+	converted := ArchiveFile{
+		Name:    file.Name,
+		Content: file.Content,
+		IsDir:   file.IsDir,
+		Kind:    FileKind(file.Kind),
+	}
+	if file.ELF != nil {
+		info := ELFInfo(*file.ELF)
+		converted.ELF = &info
+	}
+	return converted
+}
+
+// ExtractArchive extracts a tar, tar.gz, tar.bz2, zip, gz or bz2 archive.
+// The format is detected from the extension of name, a file name or URL, and
+// from the content. It returns the format and the classified entries.
+func ExtractArchive(ctx context.Context, name string, data []byte) (string, []ArchiveFile, error) {
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+	format, files, err := github.Extract(name, data)
+	if err != nil {
+		return format, nil, err
+	}
+	converted := make([]ArchiveFile, len(files))
+	for i, file := range files {
+		converted[i] = newArchiveFile(file)
+	}
+	return format, converted, nil
+}
@@ -0,0 +1,53 @@
+package nanolayer
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+)
+
+func TestFileKindsMatchInstaller(t *testing.T) {
+	kinds := map[github.FileKind]FileKind{
+		github.KindDirectory: KindDirectory,
+		github.KindELF:       KindELF,
+		github.KindScript:    KindScript,
+		github.KindData:      KindData,
+	}
+	for internal, public := range kinds {
+		if FileKind(internal) != public {
+			t.Fatalf("FileKind %q does not match the installer's %q", public, internal)
+		}
+	}
+}
+
+func TestExtractArchiveDescribesELFBinaries(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to find test binary: %v", err)
+	}
+	content, err := os.ReadFile(executable)
+	if err != nil {
+		t.Fatalf("failed to read test binary: %v", err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "tool", Mode: 0755, Size: int64(len(content))})
+	tw.Write(content)
+	tw.Close()
+
+	_, files, err := ExtractArchive(context.Background(), "tool.tar", buf.Bytes())
+	if err != nil {
+		t.Fatalf("ExtractArchive returned error: %v", err)
+	}
+	if len(files) != 1 || files[0].Kind != KindELF || files[0].ELF == nil {
+		t.Fatalf("expected one ELF binary, got %+v", files)
+	}
+	if !files[0].ELF.Matches(DetectArchitecture()) {
+		t.Fatalf("expected the test binary to match the running architecture, got %+v", files[0].ELF)
+	}
+}
@@ -0,0 +1,57 @@
+package nanolayer
+
+import (
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+)
+
+// CacheEntry describes a download kept in the cache
+type CacheEntry struct {
+	URL      string
+	SHA256   string
+	Size     int64
+	LastUsed time.Time
+}
+
+// CacheDir returns the directory of the download cache set by Configure, ""
+// if the cache is disabled
+func CacheDir() string {
+	if download.Default.Cache == nil {
+		return ""
+	}
+	return download.Default.Cache.Dir
+}
+
+// ListCache returns the downloads in the cache, none if it is disabled
+func ListCache() ([]CacheEntry, error) {
+	if download.Default.Cache == nil {
+		return nil, nil
+	}
+	entries, err := download.Default.Cache.List()
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]CacheEntry, len(entries))
+	for i, entry := range entries {
+		converted[i] = CacheEntry{URL: entry.URL, SHA256: entry.SHA256, Size: entry.Size, LastUsed: entry.LastUsed}
+	}
+	return converted, nil
+}
+
+// PruneCache removes the downloads not used within olderThan and returns
+// the number of bytes freed
+func PruneCache(olderThan time.Duration) (int64, error) {
+	if download.Default.Cache == nil {
+		return 0, nil
+	}
+	return download.Default.Cache.Prune(olderThan)
+}
+
+// ClearCache removes every download from the cache
+func ClearCache() error {
+	if download.Default.Cache == nil {
+		return nil
+	}
+	return download.Default.Cache.Clear()
+}
@@ -0,0 +1,101 @@
+package nanolayer
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/httpclient"
+	"github.com/devcontainer-community/nanolayer-go/internal/progress"
+)
+
+// Config holds the download and HTTP settings shared by every installer of
+// the process. Start from DefaultConfig and apply it with Configure.
+type Config struct {
+	// CacheDir is the shared download cache, disabled if empty
+	CacheDir string
+	// Offline only uses the download cache and never accesses the network
+	Offline bool
+	// Retries is the number of times an interrupted download is resumed
+	Retries int
+	// Chunks downloads large files as this many parallel ranges when the
+	// server supports it
+	Chunks int
+	// Progress receives download progress, none is reported if nil
+	Progress io.Writer
+
+	// HTTPTimeout limits each HTTP request, no limit if zero
+	HTTPTimeout time.Duration
+	// CACertFile is a PEM bundle trusted in addition to the system roots,
+	// $NANOLAYER_CA_BUNDLE if empty
+	CACertFile string
+	// ClientCertFile and ClientKeyFile enable TLS client authentication
+	ClientCertFile string
+	ClientKeyFile  string
+	// InsecureSkipVerify disables TLS certificate verification
+	InsecureSkipVerify bool
+
+	// MirrorConfig is a file of "prefix=replacement" URL rewrites, one per line
+	MirrorConfig string
+	// Mirrors are "prefix=replacement" URL rewrites applied after those of
+	// MirrorConfig
+	Mirrors []string
+}
+
+// DefaultConfig returns the settings nanolayer starts with, taking the cache
+// directory, offline mode and mirror config from $NANOLAYER_CACHE_DIR,
+// $NANOLAYER_OFFLINE and $NANOLAYER_MIRROR_CONFIG
+func DefaultConfig() Config {
+	cacheDir := ""
+	if download.Default.Cache != nil {
+		cacheDir = download.Default.Cache.Dir
+	}
+	return Config{
+		CacheDir:     cacheDir,
+		Offline:      download.Default.Offline,
+		Retries:      download.Default.Retries,
+		Chunks:       download.Default.Chunks,
+		MirrorConfig: os.Getenv(httpclient.MIRROR_CONFIG_ENV),
+	}
+}
+
+// Configure applies cfg to the installers run afterwards
+func Configure(cfg Config) error {
+	err := httpclient.Configure(httpclient.Options{
+		Timeout:            cfg.HTTPTimeout,
+		CACertFile:         cfg.CACertFile,
+		ClientCertFile:     cfg.ClientCertFile,
+		ClientKeyFile:      cfg.ClientKeyFile,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	})
+	if err != nil {
+		return err
+	}
+
+	var rewrites []httpclient.Rewrite
+	if cfg.MirrorConfig != "" {
+		rewrites, err = httpclient.LoadRewrites(cfg.MirrorConfig)
+		if err != nil {
+			return err
+		}
+	}
+	for _, mirror := range cfg.Mirrors {
+		rewrite, err := httpclient.ParseRewrite(mirror)
+		if err != nil {
+			return err
+		}
+		rewrites = append(rewrites, rewrite)
+	}
+	httpclient.Rewrites = rewrites
+
+	download.Default.Cache = download.NewCache(cfg.CacheDir)
+	download.Default.Offline = cfg.Offline
+	download.Default.Retries = cfg.Retries
+	download.Default.Chunks = cfg.Chunks
+	download.Default.Progress = nil
+	if cfg.Progress != nil {
+		download.Default.Progress = progress.New(cfg.Progress, false)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package nanolayer
+
+import (
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	previous := DefaultConfig()
+	t.Cleanup(func() { Configure(previous) })
+
+	cfg := DefaultConfig()
+	cfg.CacheDir = t.TempDir()
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	if CacheDir() != cfg.CacheDir {
+		t.Fatalf("expected cache dir %q, got %q", cfg.CacheDir, CacheDir())
+	}
+	if entries, err := ListCache(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty cache, got %v (%v)", entries, err)
+	}
+
+	cfg.CacheDir = ""
+	cfg.Mirrors = []string{"https://github.com"}
+	if err := Configure(cfg); err == nil {
+		t.Fatalf("expected error for a mirror without replacement")
+	}
+
+	cfg.Mirrors = nil
+	if err := Configure(cfg); err != nil || CacheDir() != "" {
+		t.Fatalf("expected the cache to be disabled, got %q (%v)", CacheDir(), err)
+	}
+}
@@ -0,0 +1,22 @@
+// Package nanolayer is the Go API of nanolayer, for tools that embed its
+// installers instead of running the command line.
+//
+// Installers take a context and an options struct and log through log/slog,
+// to the Output writer of the options or the default logger if nil. Records
+// carry an "event" attribute: resolve, download, extract, install or verify;
+// see ConfigureLogging. Canceling the context aborts downloads and
+// terminates package managers after their cleanup steps ran:
+//
+//   - InstallGitHub and PlanGitHub install a tool from a GitHub release
+//   - InstallAPK and PlanAPK install Alpine packages
+//   - Apply, PlanApply and Lock work on nanolayer.yaml manifests
+//   - Mirror copies releases for offline use
+//
+// Failures wrap the errors declared in this package, such as ErrAssetNotFound
+// or ErrRateLimited, so callers can tell them apart with errors.Is.
+//
+// Configure sets the download cache, offline mode, mirrors and TLS settings
+// shared by all installers. Facts describes the system the installers
+// detect, and ExtractArchive exposes the archive extraction used for release
+// assets.
+package nanolayer
@@ -0,0 +1,62 @@
+package nanolayer
+
+import (
+	"context"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/shellenv"
+)
+
+// EnvEntry is a PATH directory or an environment variable kept in a block of
+// the shell startup files
+type EnvEntry struct {
+	entry shellenv.Entry
+}
+
+// ParsePathEntry parses a directory to prepend to PATH, making a relative one
+// absolute
+func ParsePathEntry(dir string) (EnvEntry, error) {
+	entry, err := shellenv.ParsePath(dir)
+	return EnvEntry{entry}, err
+}
+
+// ParseVarEntry parses a KEY=VALUE assignment to export
+func ParseVarEntry(assignment string) (EnvEntry, error) {
+	entry, err := shellenv.ParseVar(assignment)
+	return EnvEntry{entry}, err
+}
+
+// EnvOptions selects the shell startup files to update
+type EnvOptions struct {
+	// User owns the startup files in the user scope, defaults to
+	// $_REMOTE_USER, $_CONTAINER_USER, $SUDO_USER or the current user
+	User string
+	// Scope is "system" for /etc/profile.d and the system shell
+	// configuration or "user" for the files in the home of User, defaults to
+	// system with root privileges and user without
+	Scope string
+}
+
+// AddEnv writes entry into the startup files selected by opts, replacing the
+// block written for it before, and returns the files that changed
+func AddEnv(ctx context.Context, opts EnvOptions, entry EnvEntry) ([]string, error) {
+	target, err := installers.ResolveTargetOrDefault(opts.Scope, opts.User)
+	if err != nil {
+		return nil, err
+	}
+	return shellenv.Add(ctx, target, entry.entry)
+}
+
+// RemoveEnv removes the blocks of the PATH directory or variable name from
+// the startup files selected by opts and returns the files that changed
+func RemoveEnv(ctx context.Context, opts EnvOptions, name string) ([]string, error) {
+	target, err := installers.ResolveTargetOrDefault(opts.Scope, opts.User)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{shellenv.VarEntry(name, "").ID()}
+	if entry, err := shellenv.ParsePath(name); err == nil {
+		ids = append(ids, entry.ID())
+	}
+	return shellenv.Remove(ctx, target, ids...)
+}
@@ -0,0 +1,37 @@
+package nanolayer
+
+import (
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/elfinspect"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"github.com/devcontainer-community/nanolayer-go/internal/parallel"
+)
+
+// Errors returned by the installers, to be checked with errors.Is
+var (
+	// ErrUnsupportedDistro: the package manager is not available on this distribution
+	ErrUnsupportedDistro = installers.ErrUnsupportedDistro
+	// ErrPrivilegesRequired: root privileges are needed and sudo or doas cannot provide them
+	ErrPrivilegesRequired = installers.ErrPrivilegesRequired
+	// ErrReleaseNotFound: the repository or a release matching the version does not exist
+	ErrReleaseNotFound = github.ErrReleaseNotFound
+	// ErrAssetNotFound: the asset URL of the release does not exist
+	ErrAssetNotFound = github.ErrAssetNotFound
+	// ErrRateLimited: the GitHub API rate limit is exhausted, see GITHUB_TOKEN
+	ErrRateLimited = github.ErrRateLimited
+	// ErrChecksumMismatch: the download does not match the expected SHA-256
+	ErrChecksumMismatch = download.ErrChecksumMismatch
+	// ErrNotFound: the server has no file at a download URL
+	ErrNotFound = download.ErrNotFound
+	// ErrNotCached: offline mode and the URL is not in the download cache
+	ErrNotCached = download.ErrNotCached
+	// ErrVerificationFailed: the verification command failed or printed another version
+	ErrVerificationFailed = github.ErrVerificationFailed
+	// ErrArchitectureMismatch: a binary is built for another architecture
+	ErrArchitectureMismatch = elfinspect.ErrArchitectureMismatch
+	// ErrMissingInterpreter: the dynamic loader of a binary does not exist, e.g. a glibc binary on musl
+	ErrMissingInterpreter = elfinspect.ErrMissingInterpreter
+	// ErrSkipped: RunParallel did not start the task after an earlier failure
+	ErrSkipped = parallel.ErrSkipped
+)
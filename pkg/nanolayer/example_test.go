@@ -0,0 +1,103 @@
+package nanolayer_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/devcontainer-community/nanolayer-go/pkg/nanolayer"
+)
+
+func ExampleInstallGitHub() {
+	err := nanolayer.InstallGitHub(context.Background(), nanolayer.GitHubOptions{
+		// gum follows DefaultAssetURLTemplate, so no template is needed
+		Repo:      "charmbracelet/gum@^0.14",
+		Scope:     "user",
+		AddToPath: true,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func ExamplePlanGitHub() {
+	step, err := nanolayer.PlanGitHub(context.Background(), nanolayer.GitHubOptions{
+		Repo:         "cli/cli",
+		AssetName:    "gh",
+		Architecture: "arm64",
+		Output:       os.Stderr,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Println(step.Version, step.URL, step.Writes)
+}
+
+func ExampleApply() {
+	results, err := nanolayer.Apply(context.Background(), nanolayer.ApplyOptions{
+		File:   "nanolayer.yaml",
+		Locked: true,
+		Jobs:   4,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	nanolayer.WriteSummary(os.Stdout, results)
+}
+
+func ExampleFacts() {
+	facts, err := nanolayer.Facts(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if fact, ok := nanolayer.LookupFact(facts, "libc"); ok {
+		fmt.Println(nanolayer.FormatValue(fact.Value))
+	}
+}
+
+func ExampleParseArchitecture() {
+	for _, name := range []string{"amd64", "aarch64", "linux/arm/v7"} {
+		architecture, ok := nanolayer.ParseArchitecture(name)
+		fmt.Println(name, architecture, ok)
+	}
+	// Output:
+	// amd64 x86_64 true
+	// aarch64 arm64 true
+	// linux/arm/v7 armv7 true
+}
+
+func ExampleParseRepository() {
+	repo, version, err := nanolayer.ParseRepository("junegunn/fzf@0.54.0")
+	fmt.Println(repo, version, err)
+	// Output: junegunn/fzf 0.54.0 <nil>
+}
+
+func ExampleExtractArchive() {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("#!/bin/sh\necho hello\n")
+	tw.WriteHeader(&tar.Header{Name: "tool/install.sh", Mode: 0755, Size: int64(len(content))})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+
+	format, files, err := nanolayer.ExtractArchive(context.Background(), "tool.tar.gz", buf.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(format)
+	for _, file := range files {
+		fmt.Println(file.Name, file.Kind)
+	}
+	// Output:
+	// tar.gz
+	// tool/install.sh script
+}
@@ -0,0 +1,53 @@
+package nanolayer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/exitcode"
+)
+
+// Exit codes of the nanolayer command line, returned by ExitCode
+const (
+	ExitOK                 = exitcode.OK
+	ExitFailure            = exitcode.FAILURE
+	ExitUsage              = exitcode.USAGE
+	ExitUnsupportedDistro  = exitcode.UNSUPPORTED_DISTRO
+	ExitNotFound           = exitcode.NOT_FOUND
+	ExitChecksumMismatch   = exitcode.CHECKSUM_MISMATCH
+	ExitNetwork            = exitcode.NETWORK
+	ExitRateLimited        = exitcode.RATE_LIMITED
+	ExitVerificationFailed = exitcode.VERIFICATION_FAILED
+	ExitPrivilegesRequired = exitcode.PRIVILEGES_REQUIRED
+	ExitTimeout            = exitcode.TIMEOUT
+	ExitInterrupted        = exitcode.INTERRUPTED
+)
+
+// UsageError marks an invalid command line, which exits with ExitUsage
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// Usagef returns a UsageError with a formatted message
+func Usagef(format string, args ...any) error {
+	return &UsageError{Err: fmt.Errorf(format, args...)}
+}
+
+// ExitCode returns the exit code of the command line for err: ExitOK if
+// nil, the code of the first error of this package it wraps, ExitUsage for
+// a UsageError and ExitFailure otherwise
+func ExitCode(err error) int {
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		err = &exitcode.UsageError{Err: err}
+	}
+	return exitcode.For(err)
+}
@@ -0,0 +1,26 @@
+package nanolayer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitFailure},
+		{Usagef("repository argument is required"), ExitUsage},
+		{fmt.Errorf("install: %w", &UsageError{Err: errors.New("bad flag")}), ExitUsage},
+		{fmt.Errorf("failed to get asset URL: %w", ErrAssetNotFound), ExitNotFound},
+		{fmt.Errorf("%w: status 403", ErrRateLimited), ExitRateLimited},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Fatalf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
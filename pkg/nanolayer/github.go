@@ -0,0 +1,217 @@
+package nanolayer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/download"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/installers/github"
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+)
+
+// DefaultAssetURLTemplate is used when GitHubOptions has no asset URL template
+const DefaultAssetURLTemplate = github.DefaultAssetUrlTemplate
+
+// GitHubOptions describes a tool to install from a GitHub release
+type GitHubOptions struct {
+	// Repo is owner/repo, optionally followed by @version
+	Repo string
+	// Version is a tag, a constraint such as ^1.2 or "latest", the default.
+	// A version given in Repo takes precedence.
+	Version string
+	// AssetName defaults to the repository name
+	AssetName string
+	// AssetURLTemplate defaults to DefaultAssetURLTemplate
+	AssetURLTemplate         string
+	ArchitectureReplacements map[string]string
+	// FileDestinations maps archive entry patterns to destinations. If
+	// empty, the entry named after the asset or else the only executable is
+	// installed to /usr/local/bin.
+	FileDestinations map[string]string
+	// Checksum is the expected SHA-256 of the asset
+	Checksum string
+	// Libc selects the "gnu" or "musl" asset, detected if empty
+	Libc string
+	// Architecture installs for another architecture than the detected one
+	Architecture Architecture
+	// User and Scope select who the tool is installed for, see the
+	// --user and --scope flags
+	User  string
+	Scope string
+	// AddToPath adds the installed directories to PATH in the shell
+	// startup files
+	AddToPath       bool
+	WithCompletions bool
+	WithManpages    bool
+	// VerifyCommand runs against the downloaded files before installing,
+	// with {bin} replaced by the binary; ExpectVersion must appear in its
+	// output
+	VerifyCommand string
+	ExpectVersion string
+	// Output receives log records, in the format set by ConfigureLogging;
+	// the default slog logger is used if nil
+	Output io.Writer
+	// Progress receives download progress, the shared progress output if nil
+	Progress io.Writer
+}
+
+func (opts GitHubOptions) logger() *slog.Logger {
+	if opts.Output != nil {
+		return logging.New(opts.Output)
+	}
+	return slog.Default()
+}
+
+// ParseRepository splits an owner/repo[@version] argument
+func ParseRepository(arg string) (repo string, version string, err error) {
+	repo, version, _ = strings.Cut(arg, "@")
+	if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("repository %q must be in the format 'owner/repo'", repo)
+	}
+	return repo, version, nil
+}
+
+// installOptions validates opts and converts them for the GitHub installer,
+// logging the effective settings
+func (opts GitHubOptions) installOptions() (github.InstallOptions, error) {
+	log := logging.Event(opts.logger(), logging.EventResolve)
+	repo, repoVersion, err := ParseRepository(opts.Repo)
+	if err != nil {
+		return github.InstallOptions{}, err
+	}
+	log.Info("Installing from GitHub repository", "repo", repo)
+
+	assetName := opts.AssetName
+	if assetName == "" {
+		assetName = repo[strings.Index(repo, "/")+1:]
+	}
+	log.Debug("Using asset name", "asset", assetName)
+
+	version := "latest"
+	if opts.Version != "" {
+		version = opts.Version
+	}
+	if repoVersion != "" {
+		version = repoVersion
+	}
+	log.Debug("Using version", "version", version)
+
+	assetURLTemplate := opts.AssetURLTemplate
+	if assetURLTemplate == "" {
+		assetURLTemplate = DefaultAssetURLTemplate
+	}
+	log.Debug("Using asset URL template", "template", assetURLTemplate)
+
+	replacements := opts.ArchitectureReplacements
+	if replacements == nil {
+		replacements = map[string]string{}
+	}
+	if len(replacements) > 0 {
+		log.Debug("Using architecture replacements", "replacements", replacements)
+	}
+	if len(opts.FileDestinations) > 0 {
+		log.Debug("Using file destinations", "destinations", opts.FileDestinations)
+	} else {
+		// The installer falls back to the only executable in the archive
+		log.Debug("Using file destinations or the only executable", "destinations", github.DefaultFileDestinations(assetName))
+	}
+
+	if opts.Libc != "" && !github.IsLibc(opts.Libc) {
+		return github.InstallOptions{}, fmt.Errorf("invalid libc %q, expected gnu or musl", opts.Libc)
+	}
+	target, err := installers.ResolveTargetOrDefault(opts.Scope, opts.User)
+	if err != nil {
+		return github.InstallOptions{}, err
+	}
+
+	installOpts := github.InstallOptions{
+		Repo:                     repo,
+		Version:                  version,
+		AssetName:                assetName,
+		AssetUrlTemplate:         assetURLTemplate,
+		ArchitectureReplacements: replacements,
+		FileDestinations:         opts.FileDestinations,
+		Checksum:                 opts.Checksum,
+		Libc:                     opts.Libc,
+		TargetArchitecture:       opts.Architecture.internal(),
+		Target:                   target,
+		AddToPath:                opts.AddToPath,
+		WithCompletions:          opts.WithCompletions,
+		WithManpages:             opts.WithManpages,
+		VerifyCommand:            opts.VerifyCommand,
+		ExpectVersion:            opts.ExpectVersion,
+		Output:                   opts.Output,
+	}
+	if opts.Progress != nil {
+		installOpts.Downloader = download.Default.WithProgressOutput(opts.Progress)
+	}
+	return installOpts, nil
+}
+
+// InstallGitHub downloads the release asset described by opts and installs
+// its files
+func InstallGitHub(ctx context.Context, opts GitHubOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	installOpts, err := opts.installOptions()
+	if err != nil {
+		return err
+	}
+	_, err = github.Install(ctx, installOpts)
+	return err
+}
+
+// PlanGitHub resolves and downloads the release asset like InstallGitHub
+// and returns the files it would write, without touching the filesystem
+func PlanGitHub(ctx context.Context, opts GitHubOptions) (Step, error) {
+	step := Step{Installer: "github", Name: opts.Repo}
+	if err := ctx.Err(); err != nil {
+		return step, err
+	}
+	installOpts, err := opts.installOptions()
+	if err != nil {
+		return step, err
+	}
+	planned, err := github.Plan(ctx, installOpts)
+	return newStep(planned), err
+}
+
+// ResolveVersion resolves "latest" or a constraint such as ~1.4 to the
+// matching release version of repo
+func ResolveVersion(ctx context.Context, repo string, version string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return github.ResolveVersion(ctx, repo, version)
+}
+
+// MirrorOptions describes releases to copy for offline use
+type MirrorOptions struct {
+	// Repo is owner/repo, optionally followed by @version, "latest" if none
+	Repo string
+	// AssetPatterns restricts the mirrored assets to names matching a glob
+	AssetPatterns []string
+	// Dir is the directory the mirror is written to
+	Dir string
+}
+
+// Mirror copies the release metadata and assets of opts.Repo to opts.Dir and
+// returns the files written
+func Mirror(ctx context.Context, opts MirrorOptions) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo, version, err := ParseRepository(opts.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		version = "latest"
+	}
+	return github.MirrorRelease(ctx, repo, version, opts.AssetPatterns, opts.Dir)
+}
@@ -0,0 +1,58 @@
+package nanolayer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		arg     string
+		repo    string
+		version string
+		ok      bool
+	}{
+		{"cli/cli", "cli/cli", "", true},
+		{"cli/cli@v2.40.0", "cli/cli", "v2.40.0", true},
+		{"cli", "", "", false},
+		{"cli/cli/extra", "", "", false},
+		{"/cli", "", "", false},
+	}
+	for _, tt := range tests {
+		repo, version, err := ParseRepository(tt.arg)
+		if (err == nil) != tt.ok || repo != tt.repo || version != tt.version {
+			t.Fatalf("ParseRepository(%q) = %q, %q, %v", tt.arg, repo, version, err)
+		}
+	}
+}
+
+func TestGitHubInstallOptions(t *testing.T) {
+	opts := GitHubOptions{Repo: "junegunn/fzf@0.54.0", Version: "0.1.0", Scope: "system", Output: io.Discard}
+	installOpts, err := opts.installOptions()
+	if err != nil {
+		t.Fatalf("installOptions failed: %v", err)
+	}
+	if installOpts.Repo != "junegunn/fzf" || installOpts.Version != "0.54.0" || installOpts.AssetName != "fzf" {
+		t.Fatalf("unexpected options %+v", installOpts)
+	}
+	if installOpts.AssetUrlTemplate != DefaultAssetURLTemplate || installOpts.ArchitectureReplacements == nil {
+		t.Fatalf("expected defaults, got %+v", installOpts)
+	}
+
+	if _, err := (GitHubOptions{Repo: "junegunn/fzf", Libc: "uclibc", Output: io.Discard}).installOptions(); err == nil {
+		t.Fatalf("expected an error for an invalid libc")
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := InstallGitHub(ctx, GitHubOptions{Repo: "cli/cli"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := Facts(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package nanolayer
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/logging"
+)
+
+// Log formats accepted by ConfigureLogging
+const (
+	LogFormatText = logging.FormatText
+	LogFormatJSON = logging.FormatJSON
+)
+
+// Events in the "event" attribute of log records
+const (
+	EventResolve  = logging.EventResolve
+	EventDownload = logging.EventDownload
+	EventExtract  = logging.EventExtract
+	EventInstall  = logging.EventInstall
+	EventVerify   = logging.EventVerify
+)
+
+// ConfigureLogging sets the format and level of the installer logs and
+// installs a matching default slog logger writing to stderr: info records by
+// default, debug records when verbose and only warnings when quiet
+func ConfigureLogging(format string, verbose bool, quiet bool) error {
+	return logging.Configure(format, verbose, quiet)
+}
+
+// NewLogger returns a logger writing to w, os.Stderr if nil, in the format
+// and level set by ConfigureLogging
+func NewLogger(w io.Writer) *slog.Logger {
+	return logging.New(w)
+}
@@ -0,0 +1,192 @@
+package nanolayer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/installers"
+	"github.com/devcontainer-community/nanolayer-go/internal/manifest"
+)
+
+// DefaultManifest is the manifest file used when ApplyOptions has none
+const DefaultManifest = manifest.DEFAULT_FILE
+
+// Result is the outcome of installing a single manifest item
+type Result struct {
+	// Kind is the package manager or tool source, e.g. apk or github
+	Kind     string
+	Name     string
+	Version  string
+	Duration time.Duration
+	// Err is the reason the item failed, nil on success
+	Err error
+}
+
+func newResults(results []manifest.Result) []Result {
+	converted := make([]Result, len(results))
+	for i, result := range results {
+		converted[i] = Result(result)
+	}
+	return converted
+}
+
+func internalResults(results []Result) []manifest.Result {
+	converted := make([]manifest.Result, len(results))
+	for i, result := range results {
+		converted[i] = manifest.Result(result)
+	}
+	return converted
+}
+
+// ApplyOptions describes a manifest to install
+type ApplyOptions struct {
+	// File is the manifest, DefaultManifest if empty
+	File string
+	// Locked installs exactly what the lock file pins and fails if it is out
+	// of date with the manifest or has packages not pinned as name=version
+	Locked bool
+	// LockFile defaults to nanolayer.lock next to the manifest
+	LockFile string
+	// Jobs is the number of tools installed concurrently
+	Jobs int
+	// FailFast skips the remaining items after the first failure
+	FailFast bool
+	// Architecture installs tools for another architecture than the detected one
+	Architecture Architecture
+	// User and Scope select who tools are installed for
+	User  string
+	Scope string
+	// Output receives the log records of each item, os.Stderr if nil
+	Output io.Writer
+}
+
+func (opts ApplyOptions) file() string {
+	if opts.File != "" {
+		return opts.File
+	}
+	return DefaultManifest
+}
+
+// load reads the manifest of opts and, with opts.Locked, its checked lock
+// file, and converts opts for the manifest package
+func (opts ApplyOptions) load() (*manifest.Manifest, manifest.ApplyOptions, error) {
+	m, err := manifest.Load(opts.file())
+	if err != nil {
+		return nil, manifest.ApplyOptions{}, err
+	}
+
+	var lock *manifest.Lockfile
+	if opts.Locked {
+		lockPath := opts.LockFile
+		if lockPath == "" {
+			lockPath = manifest.LockPath(opts.file())
+		}
+		lock, err = manifest.LoadLock(lockPath)
+		if err != nil {
+			return nil, manifest.ApplyOptions{}, err
+		}
+		if err := lock.Check(m); err != nil {
+			return nil, manifest.ApplyOptions{}, err
+		}
+	}
+
+	target, err := installers.ResolveTargetOrDefault(opts.Scope, opts.User)
+	if err != nil {
+		return nil, manifest.ApplyOptions{}, err
+	}
+	return m, manifest.ApplyOptions{
+		Lock:         lock,
+		Jobs:         opts.Jobs,
+		FailFast:     opts.FailFast,
+		Output:       opts.Output,
+		Architecture: opts.Architecture.internal(),
+		Target:       target,
+	}, nil
+}
+
+// Apply installs every package and tool of the manifest and returns one
+// result per item. The error reports a manifest or lock file that cannot be
+// used; failed items are only recorded in their result.
+func Apply(ctx context.Context, opts ApplyOptions) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m, applyOpts, err := opts.load()
+	if err != nil {
+		return nil, err
+	}
+	return newResults(manifest.Apply(ctx, m, applyOpts)), nil
+}
+
+// PlanApply resolves every item of the manifest like Apply and returns what
+// Apply would do, without installing anything
+func PlanApply(ctx context.Context, opts ApplyOptions) (*Plan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m, applyOpts, err := opts.load()
+	if err != nil {
+		return nil, err
+	}
+	return newPlan(manifest.Plan(ctx, m, applyOpts)), nil
+}
+
+// Failed reports whether any result carries an error
+func Failed(results []Result) bool {
+	return manifest.Failed(internalResults(results))
+}
+
+// Err returns the error of the first item that failed, nil if all succeeded
+func Err(results []Result) error {
+	return manifest.Err(internalResults(results))
+}
+
+// WriteSummary writes a table of results to w
+func WriteSummary(w io.Writer, results []Result) {
+	manifest.WriteSummary(w, internalResults(results))
+}
+
+// LockOptions describes a manifest to resolve into a lock file
+type LockOptions struct {
+	// File is the manifest, DefaultManifest if empty
+	File string
+	// LockFile defaults to nanolayer.lock next to the manifest
+	LockFile string
+	// Architectures are resolved for every tool, x86_64 and arm64 if empty
+	Architectures []Architecture
+}
+
+// Lock resolves every tool of the manifest to an exact version, asset URL
+// and SHA-256 per architecture, writes the lock file and returns its path
+func Lock(ctx context.Context, opts LockOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	file := opts.File
+	if file == "" {
+		file = DefaultManifest
+	}
+	path := opts.LockFile
+	if path == "" {
+		path = manifest.LockPath(file)
+	}
+	architectures := internalArchitectures(opts.Architectures)
+	if len(architectures) == 0 {
+		architectures = manifest.DefaultLockArchitectures
+	}
+
+	m, err := manifest.Load(file)
+	if err != nil {
+		return "", err
+	}
+	lock, err := manifest.Lock(ctx, m, architectures)
+	if err != nil {
+		return "", fmt.Errorf("failed to lock %s: %w", file, err)
+	}
+	if err := lock.Write(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,34 @@
+package nanolayer
+
+import (
+	"context"
+	"io"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/parallel"
+)
+
+// Task is a unit of work run by RunParallel that writes its output to out
+type Task func(out io.Writer) error
+
+// ParallelOptions configures RunParallel
+type ParallelOptions struct {
+	// Jobs is the maximum number of tasks running at once, 1 if less than 1
+	Jobs int
+	// FailFast stops starting new tasks after the first failure, which
+	// return ErrSkipped
+	FailFast bool
+	// Output receives the output of every task, discarded if nil
+	Output io.Writer
+}
+
+// RunParallel runs tasks on a bounded worker pool and returns their errors
+// in task order. The output of each task is written to opts.Output in task
+// order, so it does not depend on scheduling. Tasks not started when ctx is
+// done get ctx.Err() as their error.
+func RunParallel(ctx context.Context, tasks []Task, opts ParallelOptions) []error {
+	converted := make([]parallel.Task, len(tasks))
+	for i, task := range tasks {
+		converted[i] = parallel.Task(task)
+	}
+	return parallel.Run(ctx, converted, parallel.Options(opts))
+}
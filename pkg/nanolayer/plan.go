@@ -0,0 +1,70 @@
+package nanolayer
+
+import (
+	"io"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/plan"
+)
+
+// Step describes what a single installation would do, as returned by the
+// Plan functions
+type Step struct {
+	Installer string     `json:"installer"`
+	Name      string     `json:"name"`
+	Version   string     `json:"version,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	Packages  []string   `json:"packages,omitempty"`
+	Writes    []string   `json:"writes,omitempty"`
+	Removes   []string   `json:"removes,omitempty"`
+	Commands  [][]string `json:"commands,omitempty"`
+	// Error describes why the step could not be planned
+	Error string `json:"error,omitempty"`
+	// Err is the error behind Error, for errors.Is
+	Err error `json:"-"`
+}
+
+// Fail records err as the reason the step could not be planned
+func (s *Step) Fail(err error) {
+	s.Err = err
+	s.Error = err.Error()
+}
+
+// Plan is a list of steps that can be written as text or JSON
+type Plan struct {
+	Steps []Step `json:"steps"`
+}
+
+// Failed reports whether any step could not be planned
+func (p *Plan) Failed() bool {
+	return p.internal().Failed()
+}
+
+// Err returns the error of the first step that could not be planned
+func (p *Plan) Err() error {
+	return p.internal().Err()
+}
+
+// Write renders the plan to w in the given format, "text" or "json"
+func (p *Plan) Write(w io.Writer, format string) error {
+	return p.internal().Write(w, format)
+}
+
+func (p *Plan) internal() *plan.Plan {
+	steps := make([]plan.Step, len(p.Steps))
+	for i, step := range p.Steps {
+		steps[i] = plan.Step(step)
+	}
+	return &plan.Plan{Steps: steps}
+}
+
+func newStep(step plan.Step) Step {
+	return Step(step)
+}
+
+func newPlan(p *plan.Plan) *Plan {
+	steps := make([]Step, len(p.Steps))
+	for i, step := range p.Steps {
+		steps[i] = newStep(step)
+	}
+	return &Plan{Steps: steps}
+}
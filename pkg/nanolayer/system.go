@@ -0,0 +1,116 @@
+package nanolayer
+
+import (
+	"context"
+	"io"
+
+	"github.com/devcontainer-community/nanolayer-go/internal"
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+// Architecture is a canonical CPU architecture name such as x86_64 or arm64
+type Architecture string
+
+// The canonical architecture names returned by DetectArchitecture and
+// ParseArchitecture
+const (
+	ARM64       Architecture = "arm64"
+	X86_64      Architecture = "x86_64"
+	ARMV5       Architecture = "armv5"
+	ARMV6       Architecture = "armv6"
+	ARMV7       Architecture = "armv7"
+	ARMHF       Architecture = "armhf"
+	ARM32       Architecture = "arm32"
+	I386        Architecture = "i386"
+	I686        Architecture = "i686"
+	PPC64       Architecture = "ppc64"
+	PPC64LE     Architecture = "ppc64le"
+	S390        Architecture = "s390"
+	RISCV64     Architecture = "riscv64"
+	LOONGARCH64 Architecture = "loongarch64"
+	MIPS64      Architecture = "mips64"
+	MIPS64LE    Architecture = "mips64le"
+	OTHER       Architecture = "other"
+)
+
+func (a Architecture) internal() linuxsystem.Architecture {
+	return linuxsystem.Architecture(a)
+}
+
+func internalArchitectures(architectures []Architecture) []linuxsystem.Architecture {
+	converted := make([]linuxsystem.Architecture, len(architectures))
+	for i, architecture := range architectures {
+		converted[i] = architecture.internal()
+	}
+	return converted
+}
+
+// Fact is a named piece of system information
+type Fact struct {
+	Key   string
+	Value any
+}
+
+func internalFacts(facts []Fact) []linuxsystem.Fact {
+	converted := make([]linuxsystem.Fact, len(facts))
+	for i, fact := range facts {
+		converted[i] = linuxsystem.Fact(fact)
+	}
+	return converted
+}
+
+// BuildInfo identifies the nanolayer build
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// Build returns the version, commit and date nanolayer was built from
+func Build() BuildInfo {
+	return BuildInfo{Version: internal.Version, Commit: internal.Commit, Date: internal.Date}
+}
+
+// DetectArchitecture returns the architecture nanolayer runs on
+func DetectArchitecture() Architecture {
+	return Architecture(linuxsystem.GetArchitecture())
+}
+
+// ParseArchitecture accepts a canonical, Go, Debian, Rust or Docker
+// architecture name, e.g. amd64 or linux/arm64
+func ParseArchitecture(value string) (Architecture, bool) {
+	architecture, ok := linuxsystem.ParseArchitecture(value)
+	return Architecture(architecture), ok
+}
+
+// Facts detects the system facts, followed by the nanolayer build information
+func Facts(ctx context.Context) ([]Fact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var facts []Fact
+	for _, fact := range linuxsystem.Facts() {
+		facts = append(facts, Fact(fact))
+	}
+	return append(facts,
+		Fact{Key: "NanolayerVersion", Value: internal.Version},
+		Fact{Key: "NanolayerCommit", Value: internal.Commit},
+		Fact{Key: "NanolayerDate", Value: internal.Date},
+	), nil
+}
+
+// LookupFact returns the fact named key, ignoring case
+func LookupFact(facts []Fact, key string) (Fact, bool) {
+	fact, ok := linuxsystem.LookupFact(internalFacts(facts), key)
+	return Fact(fact), ok
+}
+
+// FormatValue renders a fact value as printed by WriteFacts in text format
+func FormatValue(value any) string {
+	return linuxsystem.FormatValue(value)
+}
+
+// WriteFacts writes facts to w as text, json, env or shell
+func WriteFacts(w io.Writer, facts []Fact, format string) error {
+	return linuxsystem.WriteFacts(w, internalFacts(facts), format)
+}
@@ -0,0 +1,45 @@
+package nanolayer
+
+import (
+	"testing"
+
+	"github.com/devcontainer-community/nanolayer-go/internal/linuxsystem"
+)
+
+func TestArchitecturesMatchLinuxsystem(t *testing.T) {
+	architectures := map[linuxsystem.Architecture]Architecture{
+		linuxsystem.ARM64:       ARM64,
+		linuxsystem.X86_64:      X86_64,
+		linuxsystem.ARMV5:       ARMV5,
+		linuxsystem.ARMV6:       ARMV6,
+		linuxsystem.ARMV7:       ARMV7,
+		linuxsystem.ARMHF:       ARMHF,
+		linuxsystem.ARM32:       ARM32,
+		linuxsystem.I386:        I386,
+		linuxsystem.I686:        I686,
+		linuxsystem.PPC64:       PPC64,
+		linuxsystem.PPC64LE:     PPC64LE,
+		linuxsystem.S390:        S390,
+		linuxsystem.RISCV64:     RISCV64,
+		linuxsystem.LOONGARCH64: LOONGARCH64,
+		linuxsystem.MIPS64:      MIPS64,
+		linuxsystem.MIPS64LE:    MIPS64LE,
+		linuxsystem.OTHER:       OTHER,
+	}
+	for internal, public := range architectures {
+		if Architecture(internal) != public {
+			t.Fatalf("Architecture %q does not match linuxsystem's %q", public, internal)
+		}
+	}
+}
+
+func TestLookupFact(t *testing.T) {
+	facts := []Fact{{Key: "Libc", Value: "musl"}, {Key: "Architecture", Value: X86_64}}
+	fact, ok := LookupFact(facts, "libc")
+	if !ok || fact.Value != "musl" {
+		t.Fatalf("expected the Libc fact, got %+v (%v)", fact, ok)
+	}
+	if _, ok := LookupFact(facts, "missing"); ok {
+		t.Fatalf("expected no fact for an unknown key")
+	}
+}